@@ -0,0 +1,173 @@
+// Package analysis holds the registry of analyzers that compute the
+// extensible properties of a string. Each analyzer computes one named,
+// typed and versioned property. Registering an analyzer is all it takes to
+// store the property with every string, return it in responses and filter
+// on it in GET /strings.
+//
+// The properties computed since the first release (length, is_palindrome,
+// word_count and the rest) are columns of their own and not analyzers.
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Kind is the JSON type of a property, which decides how it is filtered
+type Kind string
+
+const (
+	// KindBoolean properties match name=true or name=false
+	KindBoolean Kind = "boolean"
+	// KindNumber properties match name=N and the bounds min_name and max_name
+	KindNumber Kind = "number"
+	// KindString properties match name=value
+	KindString Kind = "string"
+	// KindObject properties map keys to counts and match name=key when the
+	// key is present
+	KindObject Kind = "object"
+)
+
+// Value is the set of types an analyzer may compute
+type Value interface {
+	~bool | ~int | ~float64 | ~string | ~map[string]int
+}
+
+// Analyzer computes one property of a value. Version must be raised whenever
+// a change would give an existing value a different property, which marks
+// every stored string for recompute.
+type Analyzer interface {
+	Name() string
+	Version() int
+	Kind() Kind
+	Analyze(value string) any
+}
+
+// New builds an Analyzer from a typed compute function
+func New[T Value](name string, version int, compute func(value string) T) Analyzer {
+	return analyzer[T]{name: name, version: version, compute: compute}
+}
+
+type analyzer[T Value] struct {
+	name    string
+	version int
+	compute func(value string) T
+}
+
+func (a analyzer[T]) Name() string {
+	return a.name
+}
+
+func (a analyzer[T]) Version() int {
+	return a.version
+}
+
+// Kind follows the underlying type, so named types such as a language code
+// are classified too
+func (a analyzer[T]) Kind() Kind {
+	switch reflect.TypeFor[T]().Kind() {
+	case reflect.Bool:
+		return KindBoolean
+	case reflect.String:
+		return KindString
+	case reflect.Map:
+		return KindObject
+	default:
+		return KindNumber
+	}
+}
+
+func (a analyzer[T]) Analyze(value string) any {
+	return a.compute(value)
+}
+
+var namePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// reserved are the built-in properties and the query parameters of
+// GET /strings, which analyzer names and their filters would shadow
+var reserved = []string{
+	"length", "is_palindrome", "unique_characters", "word_count", "sha256_hash",
+	"character_frequency_map", "analyzer_version",
+	"entropy", "letter_count", "digit_count", "whitespace_count", "punctuation_count",
+	"symbol_count", "uppercase_count", "lowercase_count", "vowel_count",
+	"consonant_count", "longest_run",
+	"contains_character", "contains_ngram", "tag", "metadata", "stale", "collection",
+	"analyze", "query", "include",
+}
+
+// Registry is an ordered set of analyzers with unique names
+type Registry struct {
+	mu        sync.RWMutex
+	analyzers []Analyzer
+}
+
+// Register adds a to the registry
+func (r *Registry) Register(a Analyzer) error {
+	name := a.Name()
+	switch {
+	case !namePattern.MatchString(name):
+		return fmt.Errorf("invalid analyzer name %q: use lowercase letters, digits and underscores", name)
+	case strings.HasPrefix(name, "min_") || strings.HasPrefix(name, "max_") || slices.Contains(reserved, name):
+		return fmt.Errorf("invalid analyzer name %q: reserved", name)
+	case a.Version() < 1:
+		return fmt.Errorf("invalid analyzer %s: version must be positive", name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if slices.ContainsFunc(r.analyzers, func(existing Analyzer) bool { return existing.Name() == name }) {
+		return fmt.Errorf("conflict: analyzer %s is already registered", name)
+	}
+	r.analyzers = append(r.analyzers, a)
+	return nil
+}
+
+// Analyzers returns the registered analyzers in registration order
+func (r *Registry) Analyzers() []Analyzer {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.analyzers)
+}
+
+// Lookup finds the analyzer called name
+func (r *Registry) Lookup(name string) (Analyzer, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, a := range r.analyzers {
+		if a.Name() == name {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// Versions maps each analyzer's name to its version
+func (r *Registry) Versions() map[string]int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	versions := make(map[string]int, len(r.analyzers))
+	for _, a := range r.analyzers {
+		versions[a.Name()] = a.Version()
+	}
+	return versions
+}
+
+// registry holds the analyzers every string is analyzed with
+var registry = &Registry{}
+
+// Register adds a to the default registry. It panics when a cannot be
+// registered, so it is meant to be called from init.
+func Register(a Analyzer) {
+	if err := registry.Register(a); err != nil {
+		panic(err)
+	}
+}
+
+// Default returns the registry every string is analyzed with
+func Default() *Registry {
+	return registry
+}
@@ -0,0 +1,236 @@
+package analysis
+
+import (
+	"cmp"
+	"embed"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Version 2 normalized the profile distance and made text past
+// maxProfileDistance undetermined, where version 1 always named the closest
+// profile. Entries detected by version 1 are stale and detected again by a
+// recompute.
+func init() {
+	Register(New("language", 2, detectLanguage))
+}
+
+// LanguageUndetermined is the language of values without enough letters to
+// tell, following BCP 47
+const LanguageUndetermined = "und"
+
+// languageNames are the English names of the detected languages, keyed by
+// ISO 639-1 code
+var languageNames = map[string]string{
+	"ar": "arabic",
+	"de": "german",
+	"el": "greek",
+	"en": "english",
+	"es": "spanish",
+	"fr": "french",
+	"he": "hebrew",
+	"hi": "hindi",
+	"hy": "armenian",
+	"it": "italian",
+	"ja": "japanese",
+	"ka": "georgian",
+	"ko": "korean",
+	"nl": "dutch",
+	"pl": "polish",
+	"pt": "portuguese",
+	"ru": "russian",
+	"sv": "swedish",
+	"th": "thai",
+	"tr": "turkish",
+	"uk": "ukrainian",
+	"zh": "chinese",
+}
+
+// scriptLanguages are the languages told apart by their script alone
+var scriptLanguages = map[Script]string{
+	ScriptGreek:      "el",
+	ScriptArmenian:   "hy",
+	ScriptGeorgian:   "ka",
+	ScriptHebrew:     "he",
+	ScriptArabic:     "ar",
+	ScriptDevanagari: "hi",
+	ScriptThai:       "th",
+	ScriptHangul:     "ko",
+	ScriptHiragana:   "ja",
+	ScriptKatakana:   "ja",
+	ScriptHan:        "zh",
+}
+
+// LanguageCode returns the code of the language with the English name
+// name, such as "fr" for "french"
+func LanguageCode(name string) (string, bool) {
+	name = strings.ToLower(name)
+	for code, languageName := range languageNames {
+		if languageName == name {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// Languages returns the codes of the detected languages, sorted
+func Languages() []string {
+	return slices.Sorted(maps.Keys(languageNames))
+}
+
+const (
+	// profileSize is the number of ranked n-grams kept per profile, which is
+	// also how far away an n-gram missing from a profile counts as ranked
+	profileSize = 5000
+	// maxProfileNGram is the longest n-gram in a profile
+	maxProfileNGram = 4
+	// minProfileLetters is the fewest letters compared against profiles;
+	// shorter values share too few n-grams with any of them
+	minProfileLetters = 8
+	// maxProfileDistance is the furthest, as outOfPlace measures it, that
+	// text may be from the closest profile of its script. Text this far away
+	// is in no profiled language, or too short to tell, and is undetermined.
+	// It was measured on sentences of three to eight words: in a profiled
+	// language they scored at most 0.383 against their own profile, while
+	// unprofiled languages, keyboard mashes and mixed scripts scored 0.386 or
+	// more. Lower, more short sentences turn undetermined; higher, text in an
+	// unprofiled language is named after the nearest profile. Some short
+	// sentences are still lost, such as Swedish "god morgon allihopa" at 0.400.
+	maxProfileDistance = 0.39
+)
+
+// samples hold a text per language written in a script shared with other
+// languages, named after the language's code
+//
+//go:embed languages/*.txt
+var samples embed.FS
+
+// languageProfile ranks the most frequent n-grams of a language's sample
+type languageProfile struct {
+	code   string
+	script Script
+	ranks  map[string]int
+}
+
+// profiles are built once; a malformed sample is a build mistake
+var profiles = mustLoadProfiles()
+
+func mustLoadProfiles() []languageProfile {
+	profiles, err := loadProfiles(samples)
+	if err != nil {
+		panic(err)
+	}
+	return profiles
+}
+
+func loadProfiles(fsys fs.FS) ([]languageProfile, error) {
+	entries, err := fs.ReadDir(fsys, "languages")
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := make([]languageProfile, 0, len(entries))
+	for _, entry := range entries {
+		code := strings.TrimSuffix(entry.Name(), ".txt")
+		if _, ok := languageNames[code]; !ok {
+			return nil, fmt.Errorf("language sample %s: %s is not a known language code", entry.Name(), code)
+		}
+		sample, err := fs.ReadFile(fsys, path.Join("languages", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, languageProfile{
+			code:   code,
+			script: dominantScript(countScripts(string(sample))),
+			ranks:  rankNGrams(string(sample)),
+		})
+	}
+	return profiles, nil
+}
+
+// rankNGrams ranks the n-grams of one to maxProfileNGram letters of value
+// by frequency, keeping the first profileSize. Words are padded with a space
+// on each side so n-grams starting or ending a word are told apart.
+func rankNGrams(value string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range words(value) {
+		for _, letters := range strings.FieldsFunc(word, func(r rune) bool { return !unicode.IsLetter(r) }) {
+			padded := []rune(" " + letters + " ")
+			for n := 1; n <= maxProfileNGram; n++ {
+				for i := 0; i+n <= len(padded); i++ {
+					if n == 1 && padded[i] == ' ' {
+						continue
+					}
+					counts[string(padded[i:i+n])]++
+				}
+			}
+		}
+	}
+
+	ngrams := slices.SortedFunc(maps.Keys(counts), func(a, b string) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+	ranks := make(map[string]int, min(len(ngrams), profileSize))
+	for rank, ngram := range ngrams[:min(len(ngrams), profileSize)] {
+		ranks[ngram] = rank
+	}
+	return ranks
+}
+
+// outOfPlace is the distance between the n-gram ranks of a value and a
+// profile: how far each n-gram of the value is ranked from its place in the
+// profile, with n-grams missing from the profile counting as far as possible.
+// It is divided by the largest possible total, so it runs from 0 for text
+// ranked like the profile to 1 for text sharing nothing with it, whatever
+// the length of the text.
+func outOfPlace(ranks, profile map[string]int) float64 {
+	if len(ranks) == 0 {
+		return 1
+	}
+	distance := 0
+	for ngram, rank := range ranks {
+		if profileRank, ok := profile[ngram]; ok {
+			distance += min(max(rank-profileRank, profileRank-rank), profileSize)
+		} else {
+			distance += profileSize
+		}
+	}
+	return float64(distance) / float64(len(ranks)*profileSize)
+}
+
+// detectLanguage returns the ISO 639-1 code of the language value is most
+// likely written in. Scripts used by a single language decide it outright;
+// Han with any kana is Japanese. Latin and Cyrillic text is compared with
+// the profile of each language written in it, which needs at least
+// minProfileLetters letters, and is undetermined when even the closest
+// profile is maxProfileDistance away or more.
+func detectLanguage(value string) string {
+	counts := countScripts(value)
+	script := dominantScript(counts)
+	if script == ScriptHan && counts[ScriptHiragana]+counts[ScriptKatakana] > 0 {
+		return "ja"
+	}
+	if code, ok := scriptLanguages[script]; ok {
+		return code
+	}
+	if counts[script] < minProfileLetters {
+		return LanguageUndetermined
+	}
+
+	ranks := rankNGrams(value)
+	language, closest := LanguageUndetermined, maxProfileDistance
+	for _, profile := range profiles {
+		if profile.script != script {
+			continue
+		}
+		if distance := outOfPlace(ranks, profile.ranks); distance < closest {
+			language, closest = profile.code, distance
+		}
+	}
+	return language
+}
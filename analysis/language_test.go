@@ -0,0 +1,87 @@
+package analysis
+
+import "testing"
+
+func TestDetectLanguage(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Where is the nearest train station?", "en"},
+		{"My sister is a doctor and works at the hospital", "en"},
+		{"Je voudrais un café, s'il vous plaît", "fr"},
+		{"Meine Schwester ist Ärztin und arbeitet im Krankenhaus", "de"},
+		{"¿Dónde está la estación de tren más cercana?", "es"},
+		{"Il libro è sul tavolo accanto alla finestra", "it"},
+		{"Muito obrigado pela sua ajuda ontem", "pt"},
+		{"Het boek ligt op de tafel naast het raam", "nl"},
+		{"Gdzie jest najbliższa stacja kolejowa?", "pl"},
+		{"Tack så mycket för hjälpen i går", "sv"},
+		{"Bu akşam arkadaşlarla sinemaya gidiyoruz", "tr"},
+		{"Сегодня вечером мы идём в кино с друзьями", "ru"},
+		{"Сьогодні ввечері ми йдемо в кіно з друзями", "uk"},
+		{"Καλημέρα σε όλους", "el"},
+		{"今日はいい天気ですね", "ja"},
+
+		// Too short to compare
+		{"bonjour", LanguageUndetermined},
+		{"12345 !!", LanguageUndetermined},
+		// Not language, or a language without a profile
+		{"xkcd qwrtz zzzz", LanguageUndetermined},
+		{"aaaaaaaaaaaa", LanguageUndetermined},
+		{"asdfghjkl qwertyuiop", LanguageUndetermined},
+		{"Dobrý den, jak se máte dnes ráno?", LanguageUndetermined},
+		{"Hyvää huomenta kaikille, kiitos paljon", LanguageUndetermined},
+		{"Jó reggelt kívánok mindenkinek", LanguageUndetermined},
+	}
+	for _, tt := range tests {
+		if got := detectLanguage(tt.value); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+// TestLanguageCutoff covers text close to minProfileLetters and
+// maxProfileDistance
+func TestLanguageCutoff(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		// Seven and eight letters
+		{"bonjour", LanguageUndetermined},
+		{"thank you", "en"},
+		{"the cat sat", "en"},
+		// Short, and within the cutoff of their profile
+		{"merci beaucoup", "fr"},
+		{"guten Morgen", "de"},
+		{"buenos días", "es"},
+		// Short, and just past the cutoff
+		{"god morgon allihopa", LanguageUndetermined},
+		{"qwerty uiop", LanguageUndetermined},
+		// Mixed scripts are compared in the dominant script, where the words
+		// of the other script match nothing
+		{"hello мир friends", LanguageUndetermined},
+		{"Привет hello world", LanguageUndetermined},
+		{"Москва Paris London", LanguageUndetermined},
+	}
+	for _, tt := range tests {
+		if got := detectLanguage(tt.value); got != tt.want {
+			t.Errorf("detectLanguage(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestOutOfPlaceIsNormalized(t *testing.T) {
+	profile := rankNGrams("the cat sat on the mat")
+	if got := outOfPlace(profile, profile); got != 0 {
+		t.Errorf("distance of a profile to itself = %v, want 0", got)
+	}
+	if got := outOfPlace(rankNGrams("xyzzy"), profile); got != 1 {
+		t.Errorf("distance of text sharing no n-gram = %v, want 1", got)
+	}
+	short, long := rankNGrams("quiz"), rankNGrams("quiz quiz buzz fly buzz quiz")
+	if outOfPlace(short, profile) != 1 || outOfPlace(long, profile) != 1 {
+		t.Error("distance depends on the length of the text")
+	}
+}
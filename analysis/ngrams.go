@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// NGramGroup is the group of the n-gram properties, which responses only
+// carry when asked for with ?include=ngrams
+const NGramGroup = "ngrams"
+
+// N-gram sizes outside these bounds are either the character frequency map
+// again or too sparse to be useful
+const (
+	MinNGramSize = 2
+	MaxNGramSize = 5
+)
+
+// Grouped is implemented by analyzers whose property is optional in
+// responses. It is stored and filterable like any other, but only returned
+// when its group is included in the request.
+type Grouped interface {
+	Group() string
+}
+
+// GroupOf returns the group of a, or "" for properties always returned
+func GroupOf(a Analyzer) string {
+	if grouped, ok := a.(Grouped); ok {
+		return grouped.Group()
+	}
+	return ""
+}
+
+// NGramPropertyName names the property holding the n-grams of size n
+func NGramPropertyName(n int) string {
+	return fmt.Sprintf("ngram_frequency_map_%d", n)
+}
+
+// RegisterNGrams registers an n-gram analyzer for every size in sizes, so
+// which sizes are computed is decided by configuration at startup
+func RegisterNGrams(sizes []int) error {
+	for _, n := range sizes {
+		if n < MinNGramSize || n > MaxNGramSize {
+			return fmt.Errorf("invalid n-gram size %d: must be between %d and %d", n, MinNGramSize, MaxNGramSize)
+		}
+		if err := registry.Register(ngramAnalyzer{Analyzer: New(NGramPropertyName(n), 1, ngramCounter(n)), size: n}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NGramSizes returns the registered n-gram sizes in ascending order
+func NGramSizes() []int {
+	var sizes []int
+	for _, a := range registry.Analyzers() {
+		if ngrams, ok := a.(ngramAnalyzer); ok {
+			sizes = append(sizes, ngrams.size)
+		}
+	}
+	slices.Sort(sizes)
+	return sizes
+}
+
+type ngramAnalyzer struct {
+	Analyzer
+	size int
+}
+
+func (ngramAnalyzer) Group() string {
+	return NGramGroup
+}
+
+// ngramCounter counts the overlapping sequences of n characters of the
+// lowercased value, spaces and punctuation included. Values shorter than n
+// have none.
+func ngramCounter(n int) func(value string) map[string]int {
+	return func(value string) map[string]int {
+		runes := []rune(strings.ToLower(value))
+		ngrams := make(map[string]int)
+		for i := 0; i+n <= len(runes); i++ {
+			ngrams[string(runes[i:i+n])]++
+		}
+		return ngrams
+	}
+}
@@ -0,0 +1,75 @@
+package analysis
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNGramCounter(t *testing.T) {
+	tests := []struct {
+		n     int
+		value string
+		want  map[string]int
+	}{
+		{2, "", map[string]int{}},
+		// Shorter than n, or exactly n
+		{3, "ab", map[string]int{}},
+		{3, "abc", map[string]int{"abc": 1}},
+		// Overlapping, lowercased, spaces and punctuation included
+		{2, "Abab", map[string]int{"ab": 2, "ba": 1}},
+		{2, "a b!", map[string]int{"a ": 1, " b": 1, "b!": 1}},
+		{4, "aaaaa", map[string]int{"aaaa": 2}},
+		// Counted in characters, not bytes
+		{2, "ÄÖü", map[string]int{"äö": 1, "öü": 1}},
+		{2, "日本語", map[string]int{"日本": 1, "本語": 1}},
+		{3, "😀😀😀😀", map[string]int{"😀😀😀": 2}},
+		{5, "привет", map[string]int{"приве": 1, "ривет": 1}},
+	}
+	for _, tt := range tests {
+		if got := ngramCounter(tt.n)(tt.value); !maps.Equal(got, tt.want) {
+			t.Errorf("ngramCounter(%d)(%q) = %v, want %v", tt.n, tt.value, got, tt.want)
+		}
+	}
+}
+
+// withRegistry runs the test against an empty default registry
+func withRegistry(t *testing.T) {
+	saved := registry
+	registry = &Registry{}
+	t.Cleanup(func() { registry = saved })
+}
+
+func TestRegisterNGrams(t *testing.T) {
+	tests := []struct {
+		sizes []int
+		want  []int
+		err   string
+	}{
+		{nil, nil, ""},
+		{[]int{2}, []int{2}, ""},
+		{[]int{5, 2, 3}, []int{2, 3, 5}, ""},
+		{[]int{1}, nil, "invalid n-gram size 1"},
+		{[]int{2, 6}, []int{2}, "invalid n-gram size 6"},
+		{[]int{3, 3}, []int{3}, "conflict"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			withRegistry(t)
+			err := RegisterNGrams(tt.sizes)
+			if tt.err == "" && err != nil || tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Fatalf("RegisterNGrams(%v) = %v, want %q", tt.sizes, err, tt.err)
+			}
+			if got := NGramSizes(); !slices.Equal(got, tt.want) {
+				t.Fatalf("NGramSizes() = %v after registering %v, want %v", got, tt.sizes, tt.want)
+			}
+			for _, n := range tt.want {
+				a, ok := Default().Lookup(NGramPropertyName(n))
+				if !ok || GroupOf(a) != NGramGroup {
+					t.Fatalf("%s is not registered in group %s", NGramPropertyName(n), NGramGroup)
+				}
+			}
+		})
+	}
+}
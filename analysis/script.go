@@ -0,0 +1,123 @@
+package analysis
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	Register(New("script", 1, classifyScript))
+}
+
+// Script is the Unicode writing system of a value
+type Script string
+
+const (
+	ScriptLatin      Script = "latin"
+	ScriptCyrillic   Script = "cyrillic"
+	ScriptGreek      Script = "greek"
+	ScriptArmenian   Script = "armenian"
+	ScriptGeorgian   Script = "georgian"
+	ScriptHebrew     Script = "hebrew"
+	ScriptArabic     Script = "arabic"
+	ScriptDevanagari Script = "devanagari"
+	ScriptThai       Script = "thai"
+	ScriptHangul     Script = "hangul"
+	ScriptHiragana   Script = "hiragana"
+	ScriptKatakana   Script = "katakana"
+	ScriptHan        Script = "han"
+	// ScriptOther is the script of letters from any script not listed
+	ScriptOther Script = "other"
+	// ScriptMixed is the script of values with letters from several scripts
+	ScriptMixed Script = "mixed"
+	// ScriptNone is the script of values without letters
+	ScriptNone Script = "none"
+)
+
+// scripts are the scripts told apart, in the order ties are broken
+var scripts = []struct {
+	script Script
+	table  *unicode.RangeTable
+}{
+	{ScriptLatin, unicode.Latin},
+	{ScriptCyrillic, unicode.Cyrillic},
+	{ScriptGreek, unicode.Greek},
+	{ScriptArmenian, unicode.Armenian},
+	{ScriptGeorgian, unicode.Georgian},
+	{ScriptHebrew, unicode.Hebrew},
+	{ScriptArabic, unicode.Arabic},
+	{ScriptDevanagari, unicode.Devanagari},
+	{ScriptThai, unicode.Thai},
+	{ScriptHangul, unicode.Hangul},
+	{ScriptHiragana, unicode.Hiragana},
+	{ScriptKatakana, unicode.Katakana},
+	{ScriptHan, unicode.Han},
+}
+
+// ParseScript returns the script called name, as classifyScript reports it
+func ParseScript(name string) (Script, bool) {
+	switch Script(name) {
+	case ScriptOther, ScriptMixed, ScriptNone:
+		return Script(name), true
+	}
+	for _, s := range scripts {
+		if s.script == Script(name) {
+			return s.script, true
+		}
+	}
+	return "", false
+}
+
+// scriptOf returns the script of the letter r
+func scriptOf(r rune) Script {
+	for _, s := range scripts {
+		if unicode.Is(s.table, r) {
+			return s.script
+		}
+	}
+	return ScriptOther
+}
+
+// countScripts counts the letters of value by script. Digits, punctuation,
+// spaces and combining marks belong to no script and are not counted.
+func countScripts(value string) map[Script]int {
+	counts := make(map[Script]int)
+	for i := 0; i < len(value); {
+		r, size := utf8.DecodeRuneInString(value[i:])
+		i += size
+		if unicode.IsLetter(r) {
+			counts[scriptOf(r)]++
+		}
+	}
+	return counts
+}
+
+// dominantScript is the script with the most letters in counts, or
+// ScriptNone without letters
+func dominantScript(counts map[Script]int) Script {
+	dominant, most := ScriptNone, 0
+	for _, s := range scripts {
+		if counts[s.script] > most {
+			dominant, most = s.script, counts[s.script]
+		}
+	}
+	if counts[ScriptOther] > most {
+		dominant = ScriptOther
+	}
+	return dominant
+}
+
+// classifyScript names the script of the letters of value. A single letter
+// of another script makes the value mixed, so Japanese, which writes Han
+// alongside kana, is mixed too.
+func classifyScript(value string) Script {
+	counts := countScripts(value)
+	switch len(counts) {
+	case 0:
+		return ScriptNone
+	case 1:
+		return dominantScript(counts)
+	default:
+		return ScriptMixed
+	}
+}
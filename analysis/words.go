@@ -0,0 +1,128 @@
+package analysis
+
+import (
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+func init() {
+	Register(New("word_frequency_map", 1, wordFrequencies))
+	Register(New("unique_word_count", 1, func(value string) int { return len(wordFrequencies(value)) }))
+	Register(New("longest_word", 1, longestWord))
+	Register(New("shortest_word", 1, shortestWord))
+	Register(New("average_word_length", 1, averageWordLength))
+	Register(New("is_word_palindrome", 1, isWordPalindrome))
+}
+
+// words splits value into lowercased words. A word is a run of letters,
+// digits and combining marks. An apostrophe between two such characters
+// belongs to the word, so "don't" and "rock'n'roll" are single words while
+// quotes around a word are dropped; a period or comma between two digits
+// keeps numbers such as 3.14 whole. Any other character, hyphens included,
+// separates words. Han ideographs are written without spaces, so each one
+// is a word of its own.
+func words(value string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, strings.ToLower(word.String()))
+			word.Reset()
+		}
+	}
+
+	var previous rune
+	for i := 0; i < len(value); {
+		r, size := utf8.DecodeRuneInString(value[i:])
+		i += size
+		next, _ := utf8.DecodeRuneInString(value[i:])
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flush()
+			word.WriteRune(r)
+			flush()
+		case isWordRune(r):
+			word.WriteRune(r)
+		case isApostrophe(r) && word.Len() > 0 && isWordRune(next) && !unicode.Is(unicode.Han, next):
+			// Typographic apostrophes are stored as the ASCII one
+			word.WriteRune('\'')
+		case (r == '.' || r == ',') && unicode.IsDigit(previous) && unicode.IsDigit(next):
+			word.WriteRune(r)
+		default:
+			flush()
+		}
+		previous = r
+	}
+	flush()
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r)
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’'
+}
+
+// wordFrequencies counts each distinct word
+func wordFrequencies(value string) map[string]int {
+	frequencies := make(map[string]int)
+	for _, word := range words(value) {
+		frequencies[word]++
+	}
+	return frequencies
+}
+
+// longestWord is the first of the longest words, measured in characters, or
+// "" without words
+func longestWord(value string) string {
+	var longest string
+	for _, word := range words(value) {
+		if utf8.RuneCountInString(word) > utf8.RuneCountInString(longest) {
+			longest = word
+		}
+	}
+	return longest
+}
+
+// shortestWord is the first of the shortest words, or "" without words
+func shortestWord(value string) string {
+	var shortest string
+	for i, word := range words(value) {
+		if i == 0 || utf8.RuneCountInString(word) < utf8.RuneCountInString(shortest) {
+			shortest = word
+		}
+	}
+	return shortest
+}
+
+// averageWordLength is the mean number of characters per word, rounded to
+// two decimals, or 0 without words
+func averageWordLength(value string) float64 {
+	tokens := words(value)
+	if len(tokens) == 0 {
+		return 0
+	}
+	characters := 0
+	for _, word := range tokens {
+		characters += utf8.RuneCountInString(word)
+	}
+	return math.Round(float64(characters)/float64(len(tokens))*100) / 100
+}
+
+// isWordPalindrome reports whether at least two words read the same in
+// reverse order, as in "fall leaves after leaves fall". Case and
+// punctuation are ignored.
+func isWordPalindrome(value string) bool {
+	tokens := words(value)
+	if len(tokens) < 2 {
+		return false
+	}
+	reversed := slices.Clone(tokens)
+	slices.Reverse(reversed)
+	return slices.Equal(tokens, reversed)
+}
@@ -0,0 +1,124 @@
+package analysis
+
+import (
+	"maps"
+	"slices"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"", nil},
+		{"  ,;  ", nil},
+		{"Hello, World!", []string{"hello", "world"}},
+		// Apostrophes inside a word keep it whole, quotes around it do not
+		{"don't stop", []string{"don't", "stop"}},
+		{"rock'n'roll", []string{"rock'n'roll"}},
+		{"it’s", []string{"it's"}},
+		{"'quoted' words'", []string{"quoted", "words"}},
+		{"l'été", []string{"l'été"}},
+		// Hyphens separate words
+		{"well-known state-of-the-art", []string{"well", "known", "state", "of", "the", "art"}},
+		{"--", nil},
+		// Numbers keep their separators between digits only
+		{"pi is 3.14, or 1,000.5", []string{"pi", "is", "3.14", "or", "1,000.5"}},
+		{"end 3. next", []string{"end", "3", "next"}},
+		// Combining marks stay with their letter
+		{"café noir", []string{"café", "noir"}},
+		// Each Han ideograph is a word; kana around it are not split
+		{"日本語", []string{"日", "本", "語"}},
+		{"東京タワー", []string{"東", "京", "タワー"}},
+		{"中文abc", []string{"中", "文", "abc"}},
+		{"Привет, МИР", []string{"привет", "мир"}},
+	}
+	for _, tt := range tests {
+		if got := words(tt.value); !slices.Equal(got, tt.want) {
+			t.Errorf("words(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestWordFrequencies(t *testing.T) {
+	tests := []struct {
+		value string
+		want  map[string]int
+	}{
+		{"", map[string]int{}},
+		{"The cat and THE hat", map[string]int{"the": 2, "cat": 1, "and": 1, "hat": 1}},
+		// Ties are all kept at the same count
+		{"a b a b c", map[string]int{"a": 2, "b": 2, "c": 1}},
+		{"don't dont don’t", map[string]int{"don't": 2, "dont": 1}},
+		{"水水", map[string]int{"水": 2}},
+	}
+	for _, tt := range tests {
+		if got := wordFrequencies(tt.value); !maps.Equal(got, tt.want) {
+			t.Errorf("wordFrequencies(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLongestAndShortestWord(t *testing.T) {
+	tests := []struct {
+		value             string
+		longest, shortest string
+	}{
+		{"", "", ""},
+		{"one", "one", "one"},
+		// The first word wins a tie
+		{"cat dog emu", "cat", "cat"},
+		{"a bb ccc bb a", "ccc", "a"},
+		// Length is measured in characters, not bytes
+		{"über abcde", "abcde", "über"},
+		{"日本 go", "go", "日"},
+	}
+	for _, tt := range tests {
+		if got := longestWord(tt.value); got != tt.longest {
+			t.Errorf("longestWord(%q) = %q, want %q", tt.value, got, tt.longest)
+		}
+		if got := shortestWord(tt.value); got != tt.shortest {
+			t.Errorf("shortestWord(%q) = %q, want %q", tt.value, got, tt.shortest)
+		}
+	}
+}
+
+func TestAverageWordLength(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"", 0},
+		{"ab abcd", 3},
+		{"a bb bb", 1.67},
+		{"日本語", 1},
+	}
+	for _, tt := range tests {
+		if got := averageWordLength(tt.value); got != tt.want {
+			t.Errorf("averageWordLength(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestIsWordPalindrome(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", false},
+		// A single word is never a word palindrome
+		{"level", false},
+		{"fall leaves after leaves fall", true},
+		{"Fall, leaves; after: LEAVES fall!", true},
+		{"you can cage a swallow, can't you?", false},
+		{"one two two one", true},
+		{"one two three", false},
+		{"日本日", true},
+	}
+	for _, tt := range tests {
+		if got := isWordPalindrome(tt.value); got != tt.want {
+			t.Errorf("isWordPalindrome(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
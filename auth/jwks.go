@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// minRefetchInterval bounds how often the JWKS is fetched, whether the
+// fetch was for stale keys or an unknown kid and whether it succeeded
+const minRefetchInterval = time.Minute
+
+// JWKS is a JSON Web Key Set as served by an OIDC provider
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWK is a single public JSON Web Key. Only the members needed for RSA, EC
+// and Ed25519 verification keys are modelled.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// PublicKey decodes the key material of the JWK
+func (k JWK) PublicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA modulus: %v", err)
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("invalid RSA exponent: %v", err)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported EC curve %q", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC x coordinate: %v", err)
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, fmt.Errorf("invalid EC y coordinate: %v", err)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported OKP curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key")
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeBigInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// KeySource serves verification keys from a JWKS file or URL. URL sources
+// are refetched periodically and whenever a token names an unknown kid.
+type KeySource struct {
+	location string
+	refresh  time.Duration
+	client   *http.Client
+	// minRefetch is minRefetchInterval, shortened by tests
+	minRefetch time.Duration
+	fetches    singleflight.Group
+
+	mu        sync.RWMutex
+	keys      map[string]crypto.PublicKey
+	fetchedAt time.Time
+	// attemptedAt is when the last fetch started, successful or not
+	attemptedAt time.Time
+}
+
+// NewKeySource loads the JWKS at location, a file path or an http(s) URL
+func NewKeySource(location string, refresh time.Duration) (*KeySource, error) {
+	source := &KeySource{
+		location: location,
+		refresh:  refresh,
+		client:   &http.Client{Timeout: 10 * time.Second},
+
+		minRefetch:  minRefetchInterval,
+		attemptedAt: time.Now(),
+	}
+	if err := source.load(); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+// Key returns the verification key for kid. An empty kid is accepted when
+// the set holds exactly one key.
+func (s *KeySource) Key(kid string) (crypto.PublicKey, error) {
+	key, known, stale := s.lookup(kid)
+	if s.isRemote() {
+		switch {
+		case !known:
+			// The key may have been rotated in, so wait for the refetch
+			<-s.refetch()
+			key, known, _ = s.lookup(kid)
+		case stale:
+			// Keep serving the current keys while they are refreshed
+			s.refetch()
+		}
+	}
+	if !known {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	return key, nil
+}
+
+func (s *KeySource) lookup(kid string) (key crypto.PublicKey, known, stale bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	stale = time.Since(s.fetchedAt) > s.refresh
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true, stale
+		}
+	}
+	key, known = s.keys[kid]
+	return key, known, stale
+}
+
+// refetch fetches the JWKS in the background unless a fetch was attempted
+// within minRefetch. Concurrent callers share one fetch, and the channel
+// receives once it is done. Failures keep the previous keys, so an
+// unreachable provider costs one fetch per interval, not one per request.
+func (s *KeySource) refetch() <-chan singleflight.Result {
+	return s.fetches.DoChan(s.location, func() (any, error) {
+		s.mu.Lock()
+		if time.Since(s.attemptedAt) < s.minRefetch {
+			s.mu.Unlock()
+			return nil, nil
+		}
+		s.attemptedAt = time.Now()
+		s.mu.Unlock()
+
+		if err := s.load(); err != nil {
+			slog.Warn("Failed to refresh JWKS", slog.String("jwks", s.location), slog.Any("error", err))
+		}
+		return nil, nil
+	})
+}
+
+func (s *KeySource) isRemote() bool {
+	return strings.HasPrefix(s.location, "http://") || strings.HasPrefix(s.location, "https://")
+}
+
+func (s *KeySource) load() error {
+	raw, err := s.read()
+	if err != nil {
+		return fmt.Errorf("failed to read JWKS: %v", err)
+	}
+
+	var set JWKS
+	if err := json.Unmarshal(raw, &set); err != nil {
+		return fmt.Errorf("failed to parse JWKS: %v", err)
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			return fmt.Errorf("failed to decode key %q: %v", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	if len(keys) == 0 {
+		return fmt.Errorf("JWKS at %s holds no signing keys", s.location)
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *KeySource) read() ([]byte, error) {
+	if !s.isRemote() {
+		return os.ReadFile(s.location)
+	}
+
+	resp, err := s.client.Get(s.location)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
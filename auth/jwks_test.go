@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer serves the JWKS of a fresh dev key and counts the fetches. It
+// answers 503 once failing is set.
+type jwksServer struct {
+	*httptest.Server
+	kid     string
+	fetches atomic.Int32
+	failing atomic.Bool
+	// block, when set, holds each fetch until it is closed
+	block chan struct{}
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	t.Helper()
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := json.Marshal(PublicJWKS(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &jwksServer{kid: SigningKeyID(key)}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.fetches.Add(1)
+		if server.block != nil {
+			<-server.block
+		}
+		if server.failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write(raw)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// newRemoteKeySource loads the server's JWKS, then lets the next fetch start
+// at once
+func newRemoteKeySource(t *testing.T, server *jwksServer) *KeySource {
+	t.Helper()
+	source, err := NewKeySource(server.URL, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source.mu.Lock()
+	source.attemptedAt = time.Time{}
+	source.mu.Unlock()
+	return source
+}
+
+func TestKeySourceThrottlesFailedFetches(t *testing.T) {
+	server := newJWKSServer(t)
+	source := newRemoteKeySource(t, server)
+	server.failing.Store(true)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := source.Key("rotated"); err == nil {
+				t.Error("unknown kid was accepted")
+			}
+		}()
+	}
+	wg.Wait()
+	for range 10 {
+		source.Key("rotated")
+	}
+
+	// One initial fetch and one refetch, however many lookups failed
+	if fetches := server.fetches.Load(); fetches != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", fetches)
+	}
+	if _, err := source.Key(server.kid); err != nil {
+		t.Fatalf("previous keys dropped after a failed fetch: %v", err)
+	}
+}
+
+func TestKeySourceRefreshesStaleKeysInBackground(t *testing.T) {
+	server := newJWKSServer(t)
+	source := newRemoteKeySource(t, server)
+	source.mu.Lock()
+	source.fetchedAt = time.Now().Add(-2 * time.Hour)
+	source.mu.Unlock()
+	server.block = make(chan struct{})
+
+	// The lookup must not wait for the refresh it starts
+	done := make(chan error, 1)
+	go func() {
+		_, err := source.Key(server.kid)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("lookup of a stale key waited for the refresh")
+	}
+
+	close(server.block)
+	<-source.refetch()
+	source.mu.RLock()
+	fetchedAt := source.fetchedAt
+	source.mu.RUnlock()
+	if time.Since(fetchedAt) > time.Minute {
+		t.Fatal("stale keys were not refreshed")
+	}
+	if fetches := server.fetches.Load(); fetches != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", fetches)
+	}
+}
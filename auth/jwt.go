@@ -0,0 +1,140 @@
+package auth
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWTConfig controls which bearer tokens are accepted and how their claims
+// map onto scopes
+type JWTConfig struct {
+	Issuer   string
+	Audience string
+	// ScopeClaim names the claim holding the caller's permissions, either a
+	// space separated string or an array of strings
+	ScopeClaim string
+	// ClaimScopes maps claim values onto scopes. Claim values that are
+	// already known scopes are granted as is.
+	ClaimScopes map[string][]string
+	Leeway      time.Duration
+}
+
+// JWTVerifier validates bearer tokens against a JWKS
+type JWTVerifier struct {
+	keys   *KeySource
+	config JWTConfig
+	parser *jwt.Parser
+}
+
+func NewJWTVerifier(keys *KeySource, config JWTConfig) *JWTVerifier {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512", "EdDSA"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(config.Leeway),
+	}
+	if config.Issuer != "" {
+		options = append(options, jwt.WithIssuer(config.Issuer))
+	}
+	if config.Audience != "" {
+		options = append(options, jwt.WithAudience(config.Audience))
+	}
+	if config.ScopeClaim == "" {
+		config.ScopeClaim = "scope"
+	}
+
+	return &JWTVerifier{
+		keys:   keys,
+		config: config,
+		parser: jwt.NewParser(options...),
+	}
+}
+
+// Verify checks the signature and registered claims of token and returns
+// the principal it describes
+func (v *JWTVerifier) Verify(token string) (*Principal, error) {
+	claims := jwt.MapClaims{}
+	_, err := v.parser.ParseWithClaims(token, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.keys.Key(kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("unauthorized: %v", err)
+	}
+
+	subject, err := claims.GetSubject()
+	if err != nil || subject == "" {
+		return nil, fmt.Errorf("unauthorized: token has no subject")
+	}
+
+	name := subject
+	if preferred, ok := claims["preferred_username"].(string); ok && preferred != "" {
+		name = preferred
+	}
+
+	return &Principal{
+		ID:     "jwt:" + subject,
+		Name:   name,
+		Scopes: v.scopes(claims[v.config.ScopeClaim]),
+	}, nil
+}
+
+func (v *JWTVerifier) scopes(claim any) []string {
+	var values []string
+	switch typed := claim.(type) {
+	case string:
+		values = strings.Fields(typed)
+	case []any:
+		for _, item := range typed {
+			if value, ok := item.(string); ok {
+				values = append(values, value)
+			}
+		}
+	}
+
+	scopes := []string{}
+	grant := func(scope string) {
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	for _, value := range values {
+		if mapped, ok := v.config.ClaimScopes[value]; ok {
+			for _, scope := range mapped {
+				grant(scope)
+			}
+			continue
+		}
+		if IsKnownScope(value) {
+			grant(value)
+		}
+	}
+	return scopes
+}
+
+// ParseClaimScopes parses a mapping such as
+// "editor=strings:read strings:write,viewer=strings:read"
+func ParseClaimScopes(spec string) (map[string][]string, error) {
+	mapping := make(map[string][]string)
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		claimValue, scopes, ok := strings.Cut(entry, "=")
+		claimValue = strings.TrimSpace(claimValue)
+		if !ok || claimValue == "" {
+			return nil, fmt.Errorf("invalid claim mapping %q", entry)
+		}
+		for _, scope := range strings.Fields(scopes) {
+			if !IsKnownScope(scope) {
+				return nil, fmt.Errorf("invalid claim mapping %q: unknown scope %q", entry, scope)
+			}
+			mapping[claimValue] = append(mapping[claimValue], scope)
+		}
+	}
+	return mapping, nil
+}
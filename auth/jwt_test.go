@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// newDevVerifier verifies with config against the JWKS of a fresh dev key
+func newDevVerifier(t *testing.T, config JWTConfig) (*JWTVerifier, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return newVerifier(t, key, config), key
+}
+
+// newVerifier writes the JWKS of key to a file and verifies against it
+func newVerifier(t *testing.T, key *ecdsa.PrivateKey, config JWTConfig) *JWTVerifier {
+	t.Helper()
+	raw, err := json.Marshal(PublicJWKS(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := NewKeySource(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewJWTVerifier(keys, config)
+}
+
+func mint(t *testing.T, key *ecdsa.PrivateKey, request TokenRequest) string {
+	t.Helper()
+	if request.TTL == 0 {
+		request.TTL = time.Hour
+	}
+	token, err := MintToken(key, request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	verifier, key := newDevVerifier(t, JWTConfig{Issuer: "dev", Audience: "strings"})
+	other, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	valid := TokenRequest{Subject: "alice", Issuer: "dev", Audience: "strings"}
+
+	// Re-sign the claims of a valid token with another key under the same kid
+	forged := func() string {
+		parsed, _, err := jwt.NewParser().ParseUnverified(mint(t, key, valid), jwt.MapClaims{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, parsed.Claims)
+		token.Header["kid"] = SigningKeyID(key)
+		signed, err := token.SignedString(other)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"bad signature", forged()},
+		{"unknown key", mint(t, other, valid)},
+		{"wrong issuer", mint(t, key, TokenRequest{Subject: "alice", Issuer: "elsewhere", Audience: "strings"})},
+		{"wrong audience", mint(t, key, TokenRequest{Subject: "alice", Issuer: "dev", Audience: "other"})},
+		{"expired", mint(t, key, TokenRequest{Subject: "alice", Issuer: "dev", Audience: "strings", TTL: -time.Minute})},
+		{"no subject", mint(t, key, TokenRequest{Issuer: "dev", Audience: "strings"})},
+		{"malformed", "not.a.token"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			principal, err := verifier.Verify(tc.token)
+			if err == nil {
+				t.Fatalf("token accepted as %+v", principal)
+			}
+			if !strings.HasPrefix(err.Error(), "unauthorized") {
+				t.Fatalf("error %q does not start with unauthorized", err)
+			}
+		})
+	}
+
+	principal, err := verifier.Verify(mint(t, key, valid))
+	if err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+	if principal.ID != "jwt:alice" || principal.Name != "alice" {
+		t.Fatalf("got principal %+v, want jwt:alice", principal)
+	}
+}
+
+func TestVerifyMapsScopeClaim(t *testing.T) {
+	claimScopes, err := ParseClaimScopes("editor=strings:read strings:write, viewer=strings:read")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		config JWTConfig
+		claims jwt.MapClaims
+		want   []string
+	}{
+		{
+			name:   "space separated scope",
+			config: JWTConfig{ClaimScopes: claimScopes},
+			claims: jwt.MapClaims{"scope": "editor viewer strings:delete unknown"},
+			want:   []string{ScopeStringsRead, ScopeStringsWrite, ScopeStringsDelete},
+		},
+		{
+			name:   "array in a custom claim",
+			config: JWTConfig{ScopeClaim: "roles", ClaimScopes: claimScopes},
+			claims: jwt.MapClaims{"roles": []string{"viewer", "admin"}, "scope": "strings:write"},
+			want:   []string{ScopeStringsRead, ScopeAdmin},
+		},
+		{
+			name:   "no claim",
+			claims: jwt.MapClaims{},
+			want:   []string{},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			verifier, key := newDevVerifier(t, tc.config)
+			tc.claims["sub"] = "alice"
+			tc.claims["preferred_username"] = "Alice"
+			tc.claims["exp"] = time.Now().Add(time.Hour).Unix()
+			token := jwt.NewWithClaims(jwt.SigningMethodES256, tc.claims)
+			token.Header["kid"] = SigningKeyID(key)
+			signed, err := token.SignedString(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			principal, err := verifier.Verify(signed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(principal.Scopes, tc.want) {
+				t.Fatalf("got scopes %v, want %v", principal.Scopes, tc.want)
+			}
+			if principal.Name != "Alice" {
+				t.Fatalf("got name %q, want the preferred username", principal.Name)
+			}
+		})
+	}
+}
+
+func TestParseClaimScopesRejectsUnknownScopes(t *testing.T) {
+	for _, spec := range []string{"editor=strings:own", "=strings:read", "editor"} {
+		if _, err := ParseClaimScopes(spec); err == nil {
+			t.Errorf("ParseClaimScopes(%q) succeeded", spec)
+		}
+	}
+}
+
+func TestDevSigningKeyRoundTrip(t *testing.T) {
+	key, err := GenerateSigningKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := EncodeSigningKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "dev-key.pem")
+	if err := os.WriteFile(path, encoded, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadSigningKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(key) || SigningKeyID(loaded) != SigningKeyID(key) {
+		t.Fatal("loaded key differs from the one written")
+	}
+
+	// A token minted with the loaded key verifies against the original's JWKS
+	verifier := newVerifier(t, key, JWTConfig{})
+	principal, err := verifier.Verify(mint(t, loaded, TokenRequest{Subject: "dev", Scopes: []string{ScopeStringsRead}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(principal.Scopes, []string{ScopeStringsRead}) {
+		t.Fatalf("got scopes %v, want strings:read", principal.Scopes)
+	}
+}
@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Scopes granted to API clients
+const (
+	ScopeStringsRead   = "strings:read"
+	ScopeStringsWrite  = "strings:write"
+	ScopeStringsDelete = "strings:delete"
+	// ScopeAdmin grants every other scope
+	ScopeAdmin = "admin"
+)
+
+// KnownScopes lists every scope that can be granted
+var KnownScopes = []string{ScopeStringsRead, ScopeStringsWrite, ScopeStringsDelete, ScopeAdmin}
+
+const principalKey = "auth.principal"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	// ID identifies the caller for attribution, e.g. "apikey:<id>"
+	ID     string
+	Name   string
+	Scopes []string
+}
+
+// HasScope reports whether the principal was granted scope, directly or
+// through the admin scope
+func (p *Principal) HasScope(scope string) bool {
+	return slices.Contains(p.Scopes, scope) || slices.Contains(p.Scopes, ScopeAdmin)
+}
+
+// IsKnownScope reports whether scope can be granted
+func IsKnownScope(scope string) bool {
+	return slices.Contains(KnownScopes, scope)
+}
+
+// SetPrincipal attaches the authenticated caller to the request
+func SetPrincipal(c *gin.Context, principal *Principal) {
+	c.Set(principalKey, principal)
+}
+
+// GetPrincipal returns the authenticated caller of the request, or nil
+func GetPrincipal(c *gin.Context) *Principal {
+	value, ok := c.Get(principalKey)
+	if !ok {
+		return nil
+	}
+	principal, _ := value.(*Principal)
+	return principal
+}
@@ -0,0 +1,17 @@
+// Package cache provides the key/value stores behind the repository cache:
+// an in-process LRU and a Redis client. Both expire entries after the TTL
+// given when they are set.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores opaque values. Get reports a missing or expired key as a miss
+// rather than an error.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"task_one/auth"
+	"time"
+)
+
+const devIssuerUsage = `usage:
+  dev-issuer keygen [-key dev-signing-key.pem] [-jwks dev-jwks.json]
+  dev-issuer token  [-key dev-signing-key.pem] -sub <subject> [-scope "strings:read strings:write"]
+                    [-iss <issuer>] [-aud <audience>] [-ttl 1h]`
+
+// runDevIssuer implements the dev-issuer subcommand, which mints tokens from
+// a local key so the bearer token flow can be tested offline. Point JWT_JWKS
+// at the JWKS written by keygen.
+func runDevIssuer(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, devIssuerUsage)
+		return 2
+	}
+
+	switch args[0] {
+	case "keygen":
+		return devIssuerKeygen(args[1:])
+	case "token":
+		return devIssuerToken(args[1:])
+	default:
+		fmt.Fprintln(os.Stderr, devIssuerUsage)
+		return 2
+	}
+}
+
+func devIssuerKeygen(args []string) int {
+	flags := flag.NewFlagSet("dev-issuer keygen", flag.ContinueOnError)
+	keyPath := flags.String("key", "dev-signing-key.pem", "where to write the private signing key")
+	jwksPath := flags.String("jwks", "dev-jwks.json", "where to write the public JWKS")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	key, err := auth.GenerateSigningKey()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate key: %v\n", err)
+		return 1
+	}
+	keyPEM, err := auth.EncodeSigningKey(key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode key: %v\n", err)
+		return 1
+	}
+	jwks, err := json.MarshalIndent(auth.PublicJWKS(key), "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode JWKS: %v\n", err)
+		return 1
+	}
+
+	if err := os.WriteFile(*keyPath, keyPEM, 0o600); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write key: %v\n", err)
+		return 1
+	}
+	if err := os.WriteFile(*jwksPath, jwks, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write JWKS: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Wrote signing key to %s and JWKS to %s (kid %s)\n", *keyPath, *jwksPath, auth.SigningKeyID(key))
+	return 0
+}
+
+func devIssuerToken(args []string) int {
+	flags := flag.NewFlagSet("dev-issuer token", flag.ContinueOnError)
+	keyPath := flags.String("key", "dev-signing-key.pem", "private signing key written by keygen")
+	subject := flags.String("sub", "", "subject of the token")
+	scope := flags.String("scope", "strings:read", "space separated scopes")
+	issuer := flags.String("iss", "", "issuer claim")
+	audience := flags.String("aud", "", "audience claim")
+	ttl := flags.Duration("ttl", time.Hour, "token lifetime")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *subject == "" {
+		fmt.Fprintln(os.Stderr, "-sub is required")
+		return 2
+	}
+
+	key, err := auth.LoadSigningKey(*keyPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load key: %v\n", err)
+		return 1
+	}
+
+	token, err := auth.MintToken(key, auth.TokenRequest{
+		Subject:  *subject,
+		Issuer:   *issuer,
+		Audience: *audience,
+		Scopes:   strings.Fields(*scope),
+		TTL:      *ttl,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to sign token: %v\n", err)
+		return 1
+	}
+
+	fmt.Println(token)
+	return 0
+}
@@ -1,36 +1,162 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"task_one/config"
 	"task_one/initializers"
+	"task_one/logging"
+	"task_one/repository"
 	"task_one/routes"
+	"task_one/services"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	// Subcommands that do not start the server
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "dev-issuer":
+			os.Exit(runDevIssuer(os.Args[2:]))
+		case "migrate":
+			os.Exit(runMigrate(os.Args[2:]))
+		case "recompute":
+			os.Exit(runRecompute(os.Args[2:]))
+		}
+	}
+
+	if err := run(); err != nil {
+		slog.Error("Server exited", slog.Any("error", err))
+		os.Exit(1)
+	}
+}
+
+func run() error {
 	// Load configuration
 	cfg := config.LoadConfig()
-	router := gin.Default()
+
+	logger, err := logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+
+	shutdownTracing, err := initializers.NewTracerProvider(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to set up tracing: %v", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			logger.Error("Failed to flush traces", slog.Any("error", err))
+		}
+	}()
+
+	// Request logging and panic recovery are registered with the routes
+	router := gin.New()
 
 	db, err := initializers.ConnectDB(cfg)
 	if err != nil {
-		log.Fatalf("Failed to connect to PostgreSQL database: %v", err)
+		return fmt.Errorf("failed to connect to PostgreSQL database: %v", err)
 	}
-	// Perform db migrations
-	err = initializers.DoMigrate(db)
+	defer initializers.CloseDB(db)
+
+	// Refuse to serve against a schema this build does not understand
+	if err := initializers.EnsureSchema(context.Background(), db, cfg.MigrateOnStart); err != nil {
+		return fmt.Errorf("failed to verify database schema: %v", err)
+	}
+
+	if err := initializers.RegisterAnalyzers(cfg); err != nil {
+		return fmt.Errorf("failed to register analyzers: %v", err)
+	}
+
+	// Register the configured admin key so the first keys can be minted
+	if cfg.AuthEnabled && cfg.AdminAPIKey != "" {
+		apiKeyService := services.NewAPIKeyService(repository.NewAPIKeyRepository(db, logger), logger)
+		if err := apiKeyService.EnsureBootstrapKey(context.Background(), cfg.AdminAPIKey); err != nil {
+			return fmt.Errorf("failed to register bootstrap API key: %v", err)
+		}
+	}
+
+	healthService, recomputeJob, err := routes.SetupRoutes(router, db, cfg, logger)
 	if err != nil {
-		log.Println("Failed to migrate db", err)
-		return 
+		return fmt.Errorf("failed to set up routes: %v", err)
+	}
+	// A cancelled recompute resumes when it is started again
+	defer recomputeJob.Stop()
+
+	// Permanently remove strings that have sat in the trash for too long
+	purger := services.NewTrashPurger(
+		repository.NewStringRepository(db, logger),
+		cfg.TrashRetentionDays,
+		cfg.TrashPurgeInterval,
+		logger,
+	)
+	purger.Start()
+	defer purger.Stop()
+
+	// Drop stored responses that can no longer be replayed
+	idempotencyPurger := services.NewIdempotencyPurger(
+		services.NewIdempotencyService(repository.NewIdempotencyRepository(db, logger), cfg.IdempotencyKeyTTL, cfg.IdempotencyLease, logger),
+		cfg.IdempotencyPurgeInterval,
+		logger,
+	)
+	idempotencyPurger.Start()
+	defer idempotencyPurger.Stop()
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%s", cfg.Port),
+		Handler:           router,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
 	}
-	routes.SetupRoutes(router, db)
 
-	addr := fmt.Sprintf(":%s", cfg.Port)
-	log.Printf("Starting server on %s", addr)
+	// Stop accepting connections on SIGINT/SIGTERM and let in-flight
+	// requests finish within the drain deadline
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		logger.Info("Starting server", slog.String("addr", server.Addr))
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+		close(serverErr)
+	}()
+
+	select {
+	case err := <-serverErr:
+		return fmt.Errorf("failed to start http server: %v", err)
+	case <-ctx.Done():
+	}
+	stop()
 
-	if err := router.Run(addr); err != nil {
-		log.Fatalf("Failed to start http server: %v", err)
+	// Fail readiness first so load balancers stop routing new requests here
+	healthService.SetDraining()
+	if cfg.ShutdownDelay > 0 {
+		logger.Info("Draining before closing listeners", slog.Duration("delay", cfg.ShutdownDelay))
+		time.Sleep(cfg.ShutdownDelay)
 	}
+
+	logger.Info("Shutting down, draining connections", slog.Duration("timeout", cfg.ShutdownTimeout))
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(drainCtx); err != nil {
+		return fmt.Errorf("failed to drain http server: %v", err)
+	}
+
+	logger.Info("Server stopped")
+	return nil
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"task_one/config"
+	"task_one/dto"
+	"task_one/initializers"
+	"task_one/logging"
+	"task_one/models"
+	"task_one/repository"
+	"task_one/services"
+)
+
+// runRecompute analyzes the entries stored by older analyzer versions again.
+// It can be interrupted and run again at any time.
+func runRecompute(args []string) int {
+	cfg := config.LoadConfig()
+
+	flags := flag.NewFlagSet("recompute", flag.ContinueOnError)
+	batchSize := flags.Int("batch", cfg.RecomputeBatchSize, "entries to analyze and save per batch")
+	pause := flags.Duration("pause", cfg.RecomputePause, "time to sleep between batches")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *batchSize <= 0 || *pause < 0 || flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	logger, err := logging.New(os.Stderr, cfg.LogLevel, cfg.LogFormat)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	slog.SetDefault(logger)
+
+	// The n-gram sizes decide which strings are stale
+	if err := initializers.RegisterAnalyzers(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	db, err := initializers.ConnectDB(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to database: %v\n", err)
+		return 1
+	}
+	defer initializers.CloseDB(db)
+
+	// Invalidate a shared cache so servers do not keep serving the old entries
+	stringCache, err := initializers.NewCache(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	stringRepo := repository.NewStringRepository(db, logger)
+	if stringCache != nil {
+		stringRepo = repository.NewCachedStringRepository(stringRepo, stringCache, cfg.CacheTTL, nil, logger)
+	}
+	stringService := services.NewStringService(stringRepo, services.NewNaturalLanguageParser(nil), initializers.NewValuePolicy(cfg), nil, logger)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	options := services.RecomputeOptions{BatchSize: *batchSize, Pause: *pause}
+	progress, err := stringService.Recompute(ctx, options, func(progress dto.RecomputeProgress) {
+		fmt.Printf("Recomputed %d of %d entries\n", progress.Processed, progress.Total)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Recompute stopped after %d entries: %v\n", progress.Processed, err)
+		return 1
+	}
+	fmt.Printf("Recompute complete, %d entries updated to analyzer version %d\n", progress.Updated, models.CurrentAnalyzerVersion)
+	return 0
+}
@@ -3,6 +3,10 @@ package config
 import (
 	"log"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -10,6 +14,102 @@ import (
 type Config struct {
 	DBUrl string
 	Port  string
+
+	// LogLevel is debug, info, warn or error; LogFormat is text or json
+	LogLevel  string
+	LogFormat string
+
+	// TracingExporter is none, otlp or stdout. OTLP spans are sent over
+	// HTTP to TracingOTLPEndpoint; the scheme selects TLS.
+	TracingExporter     string
+	TracingOTLPEndpoint string
+	TracingSampleRatio  float64
+
+	// HTTP server timeouts. ShutdownDelay is how long readiness fails before
+	// listeners close; ShutdownTimeout then bounds how long in-flight
+	// requests may take to finish.
+	ReadHeaderTimeout time.Duration
+	ReadTimeout       time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	ShutdownDelay     time.Duration
+	ShutdownTimeout   time.Duration
+
+	// Database connection pool sizing; 0 keeps the database/sql default
+	DBMaxOpenConns    int
+	DBMaxIdleConns    int
+	DBConnMaxLifetime time.Duration
+	DBConnMaxIdleTime time.Duration
+
+	// MigrateOnStart applies pending migrations before serving instead of
+	// refusing to start
+	MigrateOnStart bool
+
+	// QueryTimeout bounds the database work of one API request; 0 disables it
+	QueryTimeout time.Duration
+
+	// Soft deleted strings older than TrashRetentionDays are purged every
+	// TrashPurgeInterval. A retention of 0 disables the purge job.
+	TrashRetentionDays int
+	TrashPurgeInterval time.Duration
+
+	// CacheBackend is none, memory or redis. The memory cache holds up to
+	// CacheSize entries; every cached lookup expires after CacheTTL.
+	CacheBackend  string
+	CacheSize     int
+	CacheTTL      time.Duration
+	CacheRedisURL string
+
+	// IdempotencyKeyTTL is how long a response is replayed for a repeated
+	// Idempotency-Key; expired keys are purged every IdempotencyPurgeInterval.
+	// IdempotencyLease is how long a key is held for a request that never
+	// finished, such as one whose server crashed, before a retry may take it.
+	IdempotencyKeyTTL        time.Duration
+	IdempotencyLease         time.Duration
+	IdempotencyPurgeInterval time.Duration
+
+	// A recompute saves RecomputeBatchSize entries at a time and sleeps for
+	// RecomputePause between batches, unless a request overrides them
+	RecomputeBatchSize int
+	RecomputePause     time.Duration
+
+	// NGramSizes are the n-gram lengths counted for every string; empty
+	// turns n-grams off
+	NGramSizes []int
+
+	// AuthEnabled requires an API key on every request. AdminAPIKey, when
+	// set, is registered as an admin key at startup to mint the first keys.
+	AuthEnabled bool
+	AdminAPIKey string
+
+	// JWTJWKS is a JWKS file path or URL; bearer tokens are only accepted
+	// when it is set. JWTClaimScopes maps claim values onto scopes, e.g.
+	// "editor=strings:read strings:write,viewer=strings:read".
+	JWTJWKS        string
+	JWTJWKSRefresh time.Duration
+	JWTIssuer      string
+	JWTAudience    string
+	JWTScopeClaim  string
+	JWTClaimScopes string
+
+	// Token bucket limits per client and route group; a rate of 0 disables
+	// the limit. DailyCreateQuota caps strings created per client per UTC
+	// day, 0 disables it.
+	RateLimitReadRPS    float64
+	RateLimitReadBurst  int
+	RateLimitWriteRPS   float64
+	RateLimitWriteBurst int
+	RateLimitNLRPS      float64
+	RateLimitNLBurst    int
+	DailyCreateQuota    int
+
+	// Limits on strings submitted for analysis; 0 means unlimited.
+	// ControlChars is "reject" or "strip".
+	MaxRequestBodyBytes int64
+	MaxValueBytes       int
+	MaxValueRunes       int
+	MaxValueWords       int
+	ControlChars        string
 }
 
 func LoadConfig() *Config {
@@ -30,6 +130,70 @@ func LoadConfig() *Config {
 	return &Config{
 		DBUrl: dbUrl,
 		Port:  getEnv("PORT", "4000"),
+
+		LogLevel:  getEnv("LOG_LEVEL", "info"),
+		LogFormat: getEnv("LOG_FORMAT", "text"),
+
+		TracingExporter:     getEnv("TRACING_EXPORTER", "none"),
+		TracingOTLPEndpoint: getEnv("TRACING_OTLP_ENDPOINT", "http://localhost:4318"),
+		TracingSampleRatio:  getEnvFloat("TRACING_SAMPLE_RATIO", 1),
+
+		ReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 5*time.Second),
+		ReadTimeout:       getEnvDuration("HTTP_READ_TIMEOUT", 15*time.Second),
+		WriteTimeout:      getEnvDuration("HTTP_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 60*time.Second),
+		ShutdownDelay:     getEnvDuration("SHUTDOWN_DELAY", 5*time.Second),
+		ShutdownTimeout:   getEnvDuration("SHUTDOWN_TIMEOUT", 20*time.Second),
+
+		DBMaxOpenConns:    getEnvInt("DB_MAX_OPEN_CONNS", 25),
+		DBMaxIdleConns:    getEnvInt("DB_MAX_IDLE_CONNS", 10),
+		DBConnMaxLifetime: getEnvDuration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+		DBConnMaxIdleTime: getEnvDuration("DB_CONN_MAX_IDLE_TIME", 5*time.Minute),
+
+		MigrateOnStart: getEnvBool("MIGRATE_ON_START", false),
+
+		QueryTimeout: getEnvDuration("QUERY_TIMEOUT", 10*time.Second),
+
+		TrashRetentionDays: getEnvInt("TRASH_RETENTION_DAYS", 30),
+		TrashPurgeInterval: getEnvDuration("TRASH_PURGE_INTERVAL", time.Hour),
+
+		CacheBackend:  getEnv("CACHE_BACKEND", "none"),
+		CacheSize:     getEnvInt("CACHE_SIZE", 10000),
+		CacheTTL:      getEnvDuration("CACHE_TTL", time.Minute),
+		CacheRedisURL: getEnv("CACHE_REDIS_URL", "redis://localhost:6379/0"),
+
+		IdempotencyKeyTTL:        getEnvDuration("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		IdempotencyLease:         getEnvDuration("IDEMPOTENCY_LEASE", time.Minute),
+		IdempotencyPurgeInterval: getEnvDuration("IDEMPOTENCY_PURGE_INTERVAL", time.Hour),
+
+		RecomputeBatchSize: getEnvInt("RECOMPUTE_BATCH_SIZE", 500),
+		RecomputePause:     getEnvDuration("RECOMPUTE_PAUSE", 100*time.Millisecond),
+
+		NGramSizes: getEnvIntList("NGRAM_SIZES", []int{2, 3}),
+
+		AuthEnabled: getEnvBool("AUTH_ENABLED", true),
+		AdminAPIKey: getEnv("ADMIN_API_KEY", ""),
+
+		JWTJWKS:        getEnv("JWT_JWKS", ""),
+		JWTJWKSRefresh: getEnvDuration("JWT_JWKS_REFRESH", 15*time.Minute),
+		JWTIssuer:      getEnv("JWT_ISSUER", ""),
+		JWTAudience:    getEnv("JWT_AUDIENCE", ""),
+		JWTScopeClaim:  getEnv("JWT_SCOPE_CLAIM", "scope"),
+		JWTClaimScopes: getEnv("JWT_CLAIM_SCOPES", ""),
+
+		RateLimitReadRPS:    getEnvFloat("RATE_LIMIT_READ_RPS", 20),
+		RateLimitReadBurst:  getEnvInt("RATE_LIMIT_READ_BURST", 40),
+		RateLimitWriteRPS:   getEnvFloat("RATE_LIMIT_WRITE_RPS", 5),
+		RateLimitWriteBurst: getEnvInt("RATE_LIMIT_WRITE_BURST", 10),
+		RateLimitNLRPS:      getEnvFloat("RATE_LIMIT_NL_RPS", 1),
+		RateLimitNLBurst:    getEnvInt("RATE_LIMIT_NL_BURST", 5),
+		DailyCreateQuota:    getEnvInt("DAILY_CREATE_QUOTA", 1000),
+
+		MaxRequestBodyBytes: int64(getEnvInt("MAX_REQUEST_BODY_BYTES", 1<<20)),
+		MaxValueBytes:       getEnvInt("MAX_VALUE_BYTES", 64<<10),
+		MaxValueRunes:       getEnvInt("MAX_VALUE_RUNES", 0),
+		MaxValueWords:       getEnvInt("MAX_VALUE_WORDS", 0),
+		ControlChars:        getEnv("CONTROL_CHARS", "reject"),
 	}
 }
 
@@ -39,3 +203,80 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer for %s, using default %d", key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getEnvIntList reads comma separated integers; an empty value is an empty
+// list
+func getEnvIntList(key string, defaultValue []int) []int {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	parsed := []int{}
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			log.Printf("Invalid integer list for %s, using default %v", key, defaultValue)
+			return defaultValue
+		}
+		if !slices.Contains(parsed, n) {
+			parsed = append(parsed, n)
+		}
+	}
+	return parsed
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Printf("Invalid number for %s, using default %g", key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid duration for %s, using default %s", key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+func getEnvBool(key string, defaultValue bool) bool {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Invalid boolean for %s, using default %t", key, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
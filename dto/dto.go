@@ -1,10 +1,26 @@
 package dto
 
 import (
+	"encoding/json"
 	"time"
 )
 
 type CreateNewStringEntryRequest struct {
+	Value    string         `json:"value"`
+	Tags     []string       `json:"tags,omitempty"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+	// CreatedBy is set from the authenticated principal, never from the body
+	CreatedBy string `json:"-"`
+}
+
+// UpdateStringEntryRequest replaces the tags and/or metadata of an entry.
+// Fields left out of the body are not touched.
+type UpdateStringEntryRequest struct {
+	Tags     *[]string       `json:"tags"`
+	Metadata *map[string]any `json:"metadata"`
+}
+
+type LookupStringRequest struct {
 	Value string `json:"value"`
 }
 
@@ -15,12 +31,51 @@ type StringProperties struct {
 	WordCount    int            `json:"word_count"`
 	SHA256Hash   string         `json:"sha256_hash"`
 	FreqMap      map[string]int `json:"character_frequency_map"`
+	// AnalyzerVersion is the version of the analysis behind these properties
+	AnalyzerVersion int `json:"analyzer_version"`
+	// Character statistics are null for strings stored before they were
+	// introduced, until they are recomputed
+	Entropy          *float64 `json:"entropy"`
+	LetterCount      *int     `json:"letter_count"`
+	DigitCount       *int     `json:"digit_count"`
+	WhitespaceCount  *int     `json:"whitespace_count"`
+	PunctuationCount *int     `json:"punctuation_count"`
+	SymbolCount      *int     `json:"symbol_count"`
+	UppercaseCount   *int     `json:"uppercase_count"`
+	LowercaseCount   *int     `json:"lowercase_count"`
+	VowelCount       *int     `json:"vowel_count"`
+	ConsonantCount   *int     `json:"consonant_count"`
+	LongestRun       *int     `json:"longest_run"`
+	// Registered holds the properties of registered analyzers by name. They
+	// are written alongside the built-in properties.
+	Registered map[string]any `json:"-"`
+}
+
+// MarshalJSON inlines the registered properties. Analyzer names cannot
+// clash with the built-in ones.
+func (p StringProperties) MarshalJSON() ([]byte, error) {
+	type builtIn StringProperties
+	data, err := json.Marshal(builtIn(p))
+	if err != nil || len(p.Registered) == 0 {
+		return data, err
+	}
+	registered, err := json.Marshal(p.Registered)
+	if err != nil {
+		return nil, err
+	}
+	// Splice the two objects: drop the closing brace of the first and the
+	// opening brace of the second
+	data = append(data[:len(data)-1], ',')
+	return append(data, registered[1:]...), nil
 }
 
 type CreateNewStringResponse struct {
 	Id         string           `json:"id"`
 	Value      string           `json:"value"`
 	Properties StringProperties `json:"properties"`
+	Tags       []string         `json:"tags"`
+	Metadata   map[string]any   `json:"metadata"`
+	CreatedBy  string           `json:"created_by,omitempty"`
 	CreatedAt  time.Time        `json:"created_at"`
 }
 
@@ -28,7 +83,16 @@ type GetStringByValueResponse struct {
 	Id         string           `json:"id"`
 	Value      string           `json:"value"`
 	Properties StringProperties `json:"properties"`
+	Tags       []string         `json:"tags"`
+	Metadata   map[string]any   `json:"metadata"`
+	CreatedBy  string           `json:"created_by,omitempty"`
 	CreatedAt  string           `json:"created_at"`
+	DeletedAt  string           `json:"deleted_at,omitempty"`
+}
+
+type TrashListResponse struct {
+	Data  []GetStringByValueResponse `json:"data"`
+	Count int                        `json:"count"`
 }
 
 type FilterByCriteriaData struct {
@@ -37,6 +101,51 @@ type FilterByCriteriaData struct {
 	MaxLength         *int    `json:"max_length,omitempty"`
 	WordCount         *int    `json:"word_count,omitempty"`
 	ContainsCharacter *string `json:"contains_character,omitempty"`
+	// ContainsNGram matches entries whose n-gram map for its length holds it
+	ContainsNGram   *string `json:"contains_ngram,omitempty"`
+	AnalyzerVersion *int    `json:"analyzer_version,omitempty"`
+	// Stale selects entries analyzed by an older version than the current one
+	// when true, and by the current version when false
+	Stale *bool `json:"stale,omitempty"`
+	// Ranges bound character statistics, keyed by statistic name
+	Ranges map[string]Range `json:"ranges,omitempty"`
+	// Properties filter the results of registered analyzers, keyed by
+	// analyzer name
+	Properties map[string]PropertyFilter `json:"properties,omitempty"`
+	// Tags must all be present on a matching entry
+	Tags []string `json:"tags,omitempty"`
+	// Metadata maps a metadata key to the value it must hold
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Range is an inclusive bound on a numeric property; either end may be open
+type Range struct {
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+}
+
+// PropertyFilter matches the property of a registered analyzer. Equals is
+// the exact value of a boolean, number or string property, or a key an
+// object property must contain. Min and Max bound number properties.
+type PropertyFilter struct {
+	Equals any      `json:"equals,omitempty"`
+	Min    *float64 `json:"min,omitempty"`
+	Max    *float64 `json:"max,omitempty"`
+}
+
+// StringStatsResponse summarises the live strings of a collection. TopNGrams
+// maps each counted n-gram size to its most frequent n-grams.
+type StringStatsResponse struct {
+	Count     int64                   `json:"count"`
+	TopNGrams map[string][]NGramCount `json:"top_ngrams"`
+}
+
+// NGramCount is the number of occurrences of an n-gram across a collection
+// and the number of strings it occurs in
+type NGramCount struct {
+	NGram       string `json:"ngram"`
+	Occurrences int64  `json:"occurrences"`
+	Strings     int64  `json:"strings"`
 }
 
 type FilterByCriteriaResponse struct {
@@ -45,6 +154,16 @@ type FilterByCriteriaResponse struct {
 	FiltersApplied map[string]any             `json:"filters_applied"`
 }
 
+// ExplainFilterResponse is PostgreSQL's plan for a filter with the parts an
+// operator usually looks for pulled out
+type ExplainFilterResponse struct {
+	Query          string          `json:"query"`
+	FiltersApplied map[string]any  `json:"filters_applied"`
+	IndexesUsed    []string        `json:"indexes_used"`
+	SequentialScan bool            `json:"sequential_scan"`
+	Plan           json.RawMessage `json:"plan"`
+}
+
 type FilterByNaturalLanguageRequest struct {
 	Query string `json:"query"`
 }
@@ -59,3 +178,94 @@ type FilterByNaturalLanguageResponse struct {
 	Count            int                        `json:"count"`
 	InterpretedQuery InterpretedQuery           `json:"interpreted_query"`
 }
+
+type CreateCollectionRequest struct {
+	Name string `json:"name"`
+}
+
+type CollectionResponse struct {
+	Name      string `json:"name"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ListCollectionsResponse struct {
+	Data  []CollectionResponse `json:"data"`
+	Count int                  `json:"count"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+}
+
+type APIKeyResponse struct {
+	Id         string   `json:"id"`
+	Name       string   `json:"name"`
+	Prefix     string   `json:"prefix"`
+	Scopes     []string `json:"scopes"`
+	CreatedAt  string   `json:"created_at"`
+	LastUsedAt string   `json:"last_used_at,omitempty"`
+	RevokedAt  string   `json:"revoked_at,omitempty"`
+}
+
+// CreateAPIKeyResponse is the only response that carries the plaintext key
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
+
+type ListAPIKeysResponse struct {
+	Data  []APIKeyResponse `json:"data"`
+	Count int              `json:"count"`
+}
+
+// StoredResponse is a response kept for replay under an idempotency key
+type StoredResponse struct {
+	StatusCode int
+	Headers    map[string]string
+	Body       []byte
+}
+
+type QuotaStatus struct {
+	Limit     int64
+	Remaining int64
+	ResetAt   time.Time
+	// Day is the UTC day the reservation was charged to, which a refund
+	// must give it back to
+	Day time.Time
+}
+
+type ReadinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type VersionResponse struct {
+	GitSHA    string `json:"git_sha"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	// SchemaVersion is the version the build expects, AppliedSchemaVersion
+	// the one the database is at
+	SchemaVersion        int  `json:"schema_version"`
+	AppliedSchemaVersion *int `json:"applied_schema_version,omitempty"`
+}
+
+// RecomputeProgress counts the work of a recompute. Total is the number of
+// stale entries when it started.
+type RecomputeProgress struct {
+	Total     int64 `json:"total"`
+	Processed int64 `json:"processed"`
+	Updated   int64 `json:"updated"`
+}
+
+// RecomputeStatus describes the current or most recent recompute job
+type RecomputeStatus struct {
+	State           string            `json:"state"`
+	AnalyzerVersion int               `json:"analyzer_version"`
+	BatchSize       int               `json:"batch_size,omitempty"`
+	Pause           string            `json:"pause,omitempty"`
+	Progress        RecomputeProgress `json:"progress"`
+	StartedAt       *time.Time        `json:"started_at,omitempty"`
+	FinishedAt      *time.Time        `json:"finished_at,omitempty"`
+	Error           string            `json:"error,omitempty"`
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"task_one/dto"
+	"task_one/middleware"
+	"task_one/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeysHandler struct {
+	apiKeyService services.APIKeyService
+	logger        *slog.Logger
+}
+
+func NewAPIKeysHandler(apiKeyService services.APIKeyService, logger *slog.Logger) *APIKeysHandler {
+	return &APIKeysHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+func (h *APIKeysHandler) CreateAPIKey(c *gin.Context) {
+	var req dto.CreateAPIKeyRequest
+	ok := bindJSON(c, &req, func(string) string {
+		return "Invalid data type; \"name\" must be a string and \"scopes\" an array of strings"
+	})
+	if !ok {
+		return
+	}
+
+	response, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), req)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to create API key")
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *APIKeysHandler) ListAPIKeys(c *gin.Context) {
+	response, err := h.apiKeyService.ListAPIKeys(c.Request.Context())
+	if err != nil {
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve API keys")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *APIKeysHandler) RevokeAPIKey(c *gin.Context) {
+	err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": "API key does not exist or is already revoked"})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to revoke API key")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"task_one/dto"
+	"task_one/middleware"
+	"task_one/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CollectionsHandler struct {
+	collectionService services.CollectionService
+	logger            *slog.Logger
+}
+
+func NewCollectionsHandler(collectionService services.CollectionService, logger *slog.Logger) *CollectionsHandler {
+	return &CollectionsHandler{
+		collectionService: collectionService,
+		logger:            logger,
+	}
+}
+
+// RequireCollection aborts with 404 when the :name collection of a scoped
+// route does not exist
+func (h *CollectionsHandler) RequireCollection(c *gin.Context) {
+	_, err := h.collectionService.GetCollection(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.AbortWithStatusJSON(http.StatusNotFound, gin.H{"message": "Collection does not exist"})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve collection")
+		return
+	}
+	c.Next()
+}
+
+func (h *CollectionsHandler) CreateCollection(c *gin.Context) {
+	var req dto.CreateCollectionRequest
+	ok := bindJSON(c, &req, func(field string) string {
+		if strings.EqualFold(field, "name") {
+			return "Invalid data type for \"name\"; must be string"
+		}
+		return ""
+	})
+	if !ok {
+		return
+	}
+
+	response, err := h.collectionService.CreateCollection(c.Request.Context(), req)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid name") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "conflict") {
+			c.JSON(http.StatusConflict, gin.H{"message": "Collection already exists"})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to create collection")
+		return
+	}
+
+	c.Header("Location", "/collections/"+response.Name)
+	c.JSON(http.StatusCreated, response)
+}
+
+func (h *CollectionsHandler) ListCollections(c *gin.Context) {
+	response, err := h.collectionService.ListCollections(c.Request.Context())
+	if err != nil {
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve collections")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *CollectionsHandler) GetCollection(c *gin.Context) {
+	response, err := h.collectionService.GetCollection(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Collection does not exist"})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve collection")
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *CollectionsHandler) DeleteCollection(c *gin.Context) {
+	err := h.collectionService.DeleteCollection(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid name") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Collection does not exist"})
+			return
+		}
+		if strings.Contains(err.Error(), "conflict") {
+			c.JSON(http.StatusConflict, gin.H{"message": "Collection is not empty"})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to delete collection")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"task_one/analysis"
+	"task_one/dto"
+	"task_one/middleware"
+	"task_one/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// cacheControl lets clients keep responses but makes them revalidate with
+// the ETag before reuse. Responses depend on the caller's credentials, so
+// shared caches must not store them.
+const cacheControl = "private, no-cache"
+
+// representation returns entry as sent to a client that included groups.
+// The registered properties are copied, so entry itself stays whole.
+func representation(entry *dto.GetStringByValueResponse, groups []string) *dto.GetStringByValueResponse {
+	sent := *entry
+	sent.Properties.Registered = maps.Clone(entry.Properties.Registered)
+	omitExcluded(groups, &sent.Properties)
+	return &sent
+}
+
+// entryETag is the strong validator of a representation: the hash of the
+// JSON body sent, so two responses with the same tag are byte for byte equal
+func entryETag(sent *dto.GetStringByValueResponse) (string, error) {
+	encoded, err := json.Marshal(sent)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(encoded)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// currentETags returns the ETag of every representation of entry, one for
+// each combination of the groups it holds properties in. A client may have
+// fetched any of them, whichever groups it included.
+func currentETags(entry *dto.GetStringByValueResponse) []string {
+	var groups []string
+	for name := range entry.Properties.Registered {
+		if analyzer, ok := analysis.Default().Lookup(name); ok {
+			if group := analysis.GroupOf(analyzer); group != "" && !slices.Contains(groups, group) {
+				groups = append(groups, group)
+			}
+		}
+	}
+
+	var etags []string
+	for mask := 0; mask < 1<<len(groups); mask++ {
+		var included []string
+		for i, group := range groups {
+			if mask&(1<<i) != 0 {
+				included = append(included, group)
+			}
+		}
+		if etag, err := entryETag(representation(entry, included)); err == nil {
+			etags = append(etags, etag)
+		}
+	}
+	return etags
+}
+
+// collectionETag is the weak validator of responses listing a collection.
+// The version moves on with every write, but two responses carrying it are
+// only equivalent, not byte for byte identical.
+func collectionETag(version int64) string {
+	return `W/"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setValidators(c *gin.Context, etag string) {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", cacheControl)
+}
+
+// notModified answers 304 when the request's If-None-Match already holds
+// etag
+func notModified(c *gin.Context, etag string) bool {
+	if !etagListMatches(c.GetHeader("If-None-Match"), etag, false) {
+		return false
+	}
+	setValidators(c, etag)
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+// listETag returns the validator of the collection's listings, answering 304
+// itself when the client's copy is current. It reports whether the request
+// has been answered, which happens before any listing work is done.
+func (h *StringsHandler) listETag(c *gin.Context) (string, bool) {
+	version, err := h.collectionService.CollectionVersion(c.Request.Context(), collectionName(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": "Collection does not exist"})
+			return "", true
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve collection")
+		return "", true
+	}
+	etag := collectionETag(version)
+	return etag, notModified(c, etag)
+}
+
+// respondWithEntry sends an entry with its validators, or 304 when the
+// client's copy is current. The ETag is that of the body sent, so it differs
+// with the groups the client included.
+func (h *StringsHandler) respondWithEntry(c *gin.Context, entry *dto.GetStringByValueResponse) {
+	sent := representation(entry, includedGroups(c))
+	etag, err := entryETag(sent)
+	if err != nil {
+		middleware.AbortServerError(c, h.logger, err, "Failed to encode string")
+		return
+	}
+	if notModified(c, etag) {
+		return
+	}
+	setValidators(c, etag)
+	c.JSON(http.StatusOK, sent)
+}
+
+// ifMatch turns the request's If-Match header into the precondition of the
+// write, which the repository checks against the stored entry while holding
+// its row. It is nil without the header.
+func ifMatch(c *gin.Context) services.Precondition {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		return nil
+	}
+	return func(current *dto.GetStringByValueResponse) bool {
+		for _, etag := range currentETags(current) {
+			if etagListMatches(header, etag, true) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// preconditionFailed answers 412 when err comes from a write whose If-Match
+// did not hold. A missing entry has no representation to match, not even *.
+func preconditionFailed(c *gin.Context, err error) bool {
+	switch {
+	case errors.Is(err, services.ErrPreconditionFailed):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"message": "Precondition failed; the string has changed"})
+	case c.GetHeader("If-Match") != "" && strings.Contains(err.Error(), "not found"):
+		c.JSON(http.StatusPreconditionFailed, gin.H{"message": "Precondition failed; the string does not exist"})
+	default:
+		return false
+	}
+	return true
+}
+
+// etagListMatches reports whether etag is in an If-Match or If-None-Match
+// header. Strong comparison, required by If-Match, never matches a weak tag.
+func etagListMatches(header, etag string, strong bool) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strong {
+			if candidate == etag && !strings.HasPrefix(etag, "W/") {
+				return true
+			}
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -2,82 +2,370 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
+	"task_one/analysis"
+	"task_one/auth"
 	"task_one/dto"
+	"task_one/middleware"
+	"task_one/models"
 	"task_one/services"
+	"unicode/utf8"
 
 	"github.com/gin-gonic/gin"
 )
 
 type StringsHandler struct {
-	stringsService services.StringService
+	stringsService    services.StringService
+	collectionService services.CollectionService
+	logger            *slog.Logger
 }
 
-func NewStringsHandler(stringService services.StringService) *StringsHandler {
+func NewStringsHandler(stringService services.StringService, collectionService services.CollectionService, logger *slog.Logger) *StringsHandler {
 	return &StringsHandler{
-		stringsService: stringService,
+		stringsService:    stringService,
+		collectionService: collectionService,
+		logger:            logger,
 	}
 }
 
+// collectionName returns the collection addressed by the request. The
+// unscoped /strings routes operate on the default collection.
+func collectionName(c *gin.Context) string {
+	if name := c.Param("name"); name != "" {
+		return name
+	}
+	return services.DefaultCollection
+}
+
+// stringsPath returns the base path of the strings routes for the request's collection
+func stringsPath(c *gin.Context) string {
+	if name := c.Param("name"); name != "" {
+		return "/collections/" + url.PathEscape(name) + "/strings"
+	}
+	return "/strings"
+}
+
 func (h *StringsHandler) CreateNewString(c *gin.Context) {
-	// Bind body
+	req, ok := bindStringRequest(c)
+	if !ok {
+		return
+	}
+
+	response, err := h.stringsService.CreateNewString(c.Request.Context(), collectionName(c), req)
+	if err != nil {
+		h.storeError(c, err)
+		return
+	}
+
+	c.Header("Location", stringsPath(c)+"/id/"+response.Id)
+	omitExcluded(includedGroups(c), &response.Properties)
+	c.JSON(http.StatusCreated, response)
+}
+
+// PutString is the idempotent form of CreateNewString: repeating it answers
+// 200 with the stored entry instead of 409
+func (h *StringsHandler) PutString(c *gin.Context) {
+	req, ok := bindStringRequest(c)
+	if !ok {
+		return
+	}
+
+	response, created, err := h.stringsService.EnsureString(c.Request.Context(), collectionName(c), req)
+	if err != nil {
+		h.storeError(c, err)
+		return
+	}
+
+	c.Header("Location", stringsPath(c)+"/id/"+response.Id)
+	omitExcluded(includedGroups(c), &response.Properties)
+	if created {
+		c.JSON(http.StatusCreated, response)
+		return
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// bindStringRequest reads the body of a create request, answering the client
+// itself when it is unusable
+func bindStringRequest(c *gin.Context) (dto.CreateNewStringEntryRequest, bool) {
 	var req dto.CreateNewStringEntryRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		// If the field "value" was the wrong type, return 422
-		if ute, ok := err.(*json.UnmarshalTypeError); ok && strings.EqualFold(ute.Field, "value") {
-			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Invalid data type for \"value\"; must be string"})
-			return
+	ok := bindJSON(c, &req, func(field string) string {
+		switch {
+		case strings.EqualFold(field, "value"):
+			return "Invalid data type for \"value\"; must be string"
+		case strings.HasPrefix(strings.ToLower(field), "tags"):
+			return "Invalid data type for \"tags\"; must be an array of strings"
+		case strings.EqualFold(field, "metadata"):
+			return "Invalid data type for \"metadata\"; must be an object"
 		}
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to read request body"})
-		return
+		return ""
+	})
+	if !ok {
+		return req, false
 	}
 
 	if len(req.Value) == 0 {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"message": "Missing value in request",
 		})
+		return req, false
+	}
+
+	// Attribute the entry to the caller
+	if principal := auth.GetPrincipal(c); principal != nil {
+		req.CreatedBy = principal.ID
+	}
+
+	return req, true
+}
+
+// bindJSON decodes the request body into req, answering the client itself
+// when the body is unusable: 413 past the body size limit, 422 with the
+// message typeMessage gives for a field of the wrong type, and 400 for
+// anything else. typeMessage returns "" for fields it has no message for.
+func bindJSON(c *gin.Context, req any, typeMessage func(field string) string) bool {
+	err := c.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Request body too large"})
+		return false
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		if message := typeMessage(typeErr.Field); message != "" {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": message})
+			return false
+		}
+	}
+	c.JSON(http.StatusBadRequest, gin.H{"message": "Failed to read request body"})
+	return false
+}
+
+// storeError answers a failed create or put
+func (h *StringsHandler) storeError(c *gin.Context, err error) {
+	if strings.Contains(err.Error(), "too large") {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"message": err.Error()})
+		return
+	}
+	if strings.Contains(err.Error(), "invalid value") {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"message": err.Error()})
+		return
+	}
+	if strings.Contains(err.Error(), "invalid tags") || strings.Contains(err.Error(), "invalid metadata") {
+		c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+		return
+	}
+	// The collection was deleted after the request resolved it
+	if strings.Contains(err.Error(), "not found") {
+		c.JSON(http.StatusNotFound, gin.H{"message": "Collection does not exist"})
 		return
 	}
+	// Map duplicate error to 409 Conflict
+	if strings.Contains(err.Error(), "conflict") {
+		c.JSON(http.StatusConflict, gin.H{"message": "String already exists in the system"})
+		return
+	}
+	middleware.AbortServerError(c, h.logger, err, "failed to get response from service layer")
+}
+
+func (h *StringsHandler) GetStringByValue(c *gin.Context) {
+	stringValue := c.Param("string_value")
 
-	response, err := h.stringsService.CreateNewString(req)
+	response, err := h.stringsService.GetStringByValue(c.Request.Context(), collectionName(c), stringValue)
 	if err != nil {
-		// Map duplicate error to 409 Conflict
-		if strings.Contains(err.Error(), "conflict") {
-			c.JSON(http.StatusConflict, gin.H{"message": "String already exists in the system"})
+		// Map not found error to 404
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": "String does not exist in the system"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "failed to get response from service layer"})
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve string")
 		return
 	}
 
-	c.JSON(http.StatusCreated, response)
+	h.respondWithEntry(c, response)
 }
 
-func (h *StringsHandler) GetStringByValue(c *gin.Context) {
-	stringValue := c.Param("string_value")
+func (h *StringsHandler) GetStringById(c *gin.Context) {
+	id := c.Param("id")
 
-	response, err := h.stringsService.GetStringByValue(stringValue)
+	response, err := h.stringsService.GetStringById(c.Request.Context(), collectionName(c), id)
 	if err != nil {
-		// Map not found error to 404
+		if strings.Contains(err.Error(), "invalid id") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid id; must be a SHA-256 hash"})
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{"message": "String does not exist in the system"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve string"})
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve string")
 		return
 	}
 
+	h.respondWithEntry(c, response)
+}
+
+// LookupString is the body based variant of GetStringByValue for values that
+// cannot be carried in a URL path segment
+func (h *StringsHandler) LookupString(c *gin.Context) {
+	var req dto.LookupStringRequest
+	ok := bindJSON(c, &req, func(field string) string {
+		if strings.EqualFold(field, "value") {
+			return "Invalid data type for \"value\"; must be string"
+		}
+		return ""
+	})
+	if !ok {
+		return
+	}
+
+	if len(req.Value) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Missing value in request"})
+		return
+	}
+
+	response, err := h.stringsService.GetStringByValue(c.Request.Context(), collectionName(c), req.Value)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": "String does not exist in the system"})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve string")
+		return
+	}
+
+	omitExcluded(includedGroups(c), &response.Properties)
 	c.JSON(http.StatusOK, response)
 }
 
-func (h *StringsHandler) FilterByCriteria(c *gin.Context) {
+func (h *StringsHandler) UpdateStringEntry(c *gin.Context) {
+	id := c.Param("id")
+	collection := collectionName(c)
+
+	var req dto.UpdateStringEntryRequest
+	ok := bindJSON(c, &req, func(string) string {
+		return "Invalid data type; \"tags\" must be an array of strings and \"metadata\" an object"
+	})
+	if !ok {
+		return
+	}
+
+	response, err := h.stringsService.UpdateStringEntry(c.Request.Context(), collection, id, req, ifMatch(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid id") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid id; must be a SHA-256 hash"})
+			return
+		}
+		if strings.Contains(err.Error(), "invalid tags") || strings.Contains(err.Error(), "invalid metadata") || strings.Contains(err.Error(), "invalid update") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": err.Error()})
+			return
+		}
+		if preconditionFailed(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": "String does not exist in the system"})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to update string")
+		return
+	}
+
+	sent := representation(response, includedGroups(c))
+	if etag, err := entryETag(sent); err == nil {
+		setValidators(c, etag)
+	}
+	c.JSON(http.StatusOK, sent)
+}
+
+// parseBound reads an optional non-negative number
+func parseBound(raw string) (*float64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	val, err := strconv.ParseFloat(raw, 64)
+	if err != nil || val < 0 || math.IsNaN(val) || math.IsInf(val, 0) {
+		return nil, errBadQuery
+	}
+	return &val, nil
+}
+
+// parseNumber reads an optional finite number
+func parseNumber(raw string) (*float64, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	val, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsNaN(val) || math.IsInf(val, 0) {
+		return nil, errBadQuery
+	}
+	return &val, nil
+}
+
+// parsePropertyFilter reads the query parameters of a registered analyzer.
+// Its name matches a value, or for objects a key; numbers also take
+// min_<name> and max_<name> bounds.
+func parsePropertyFilter(c *gin.Context, analyzer analysis.Analyzer) (dto.PropertyFilter, error) {
+	var filter dto.PropertyFilter
+	name := analyzer.Name()
+	raw := c.Query(name)
+
+	switch analyzer.Kind() {
+	case analysis.KindBoolean:
+		if raw != "" {
+			val, err := strconv.ParseBool(raw)
+			if err != nil {
+				return filter, errBadQuery
+			}
+			filter.Equals = val
+		}
+	case analysis.KindNumber:
+		equals, err := parseNumber(raw)
+		if err != nil {
+			return filter, err
+		}
+		if equals != nil {
+			filter.Equals = *equals
+		}
+		if filter.Min, err = parseNumber(c.Query("min_" + name)); err != nil {
+			return filter, err
+		}
+		if filter.Max, err = parseNumber(c.Query("max_" + name)); err != nil {
+			return filter, err
+		}
+		if filter.Min != nil && filter.Max != nil && *filter.Min > *filter.Max {
+			return filter, errBadQuery
+		}
+	default:
+		if raw != "" {
+			filter.Equals = raw
+		}
+	}
+	return filter, nil
+}
+
+// errBadQuery rejects filter query parameters that do not parse
+var errBadQuery = errors.New("invalid: bad query")
+
+// parseFilterCriteria reads the filter query parameters shared by the list
+// and explain endpoints
+func parseFilterCriteria(c *gin.Context) (dto.FilterByCriteriaData, error) {
 	isPalindrome := c.Query("is_palindrome")
 	minLength := c.Query("min_length")
 	maxLength := c.Query("max_length")
 	wordCount := c.Query("word_count")
 	containsCharacter := c.Query("contains_character")
+	containsNGram := c.Query("contains_ngram")
 
 	input := dto.FilterByCriteriaData{}
 
@@ -90,8 +378,7 @@ func (h *StringsHandler) FilterByCriteria(c *gin.Context) {
 			val := false
 			input.IsPalindrome = &val
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad query"})
-			return
+			return input, errBadQuery
 		}
 	}
 
@@ -99,8 +386,7 @@ func (h *StringsHandler) FilterByCriteria(c *gin.Context) {
 	if minLength != "" {
 		val, err := strconv.Atoi(minLength)
 		if err != nil || val < 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad query"})
-			return
+			return input, errBadQuery
 		}
 		input.MinLength = &val
 	}
@@ -109,8 +395,7 @@ func (h *StringsHandler) FilterByCriteria(c *gin.Context) {
 	if maxLength != "" {
 		val, err := strconv.Atoi(maxLength)
 		if err != nil || val < 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad query"})
-			return
+			return input, errBadQuery
 		}
 		input.MaxLength = &val
 	}
@@ -119,17 +404,33 @@ func (h *StringsHandler) FilterByCriteria(c *gin.Context) {
 	if wordCount != "" {
 		val, err := strconv.Atoi(wordCount)
 		if err != nil || val < 0 {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad query"})
-			return
+			return input, errBadQuery
 		}
 		input.WordCount = &val
 	}
 
+	// Parse and validate analyzer_version
+	if raw := c.Query("analyzer_version"); raw != "" {
+		val, err := strconv.Atoi(raw)
+		if err != nil || val < 0 {
+			return input, errBadQuery
+		}
+		input.AnalyzerVersion = &val
+	}
+
+	// Parse stale, which selects entries awaiting a recompute
+	if raw := c.Query("stale"); raw != "" {
+		val, err := strconv.ParseBool(raw)
+		if err != nil {
+			return input, errBadQuery
+		}
+		input.Stale = &val
+	}
+
 	// Validate min_length <= max_length
 	if input.MinLength != nil && input.MaxLength != nil {
 		if *input.MinLength > *input.MaxLength {
-			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad query"})
-			return
+			return input, errBadQuery
 		}
 	}
 
@@ -138,9 +439,158 @@ func (h *StringsHandler) FilterByCriteria(c *gin.Context) {
 		input.ContainsCharacter = &containsCharacter
 	}
 
-	response, err := h.stringsService.FilterByCriteria(input)
+	// Parse contains_ngram, whose length must be a counted n-gram size
+	if containsNGram != "" {
+		if !slices.Contains(analysis.NGramSizes(), utf8.RuneCountInString(containsNGram)) {
+			return input, errBadQuery
+		}
+		input.ContainsNGram = &containsNGram
+	}
+
+	// Parse min_<statistic> and max_<statistic> bounds
+	for _, name := range models.StatisticColumns {
+		minimum, err := parseBound(c.Query("min_" + name))
+		if err != nil {
+			return input, err
+		}
+		maximum, err := parseBound(c.Query("max_" + name))
+		if err != nil {
+			return input, err
+		}
+		bounds := dto.Range{Min: minimum, Max: maximum}
+		if bounds.Min != nil && bounds.Max != nil && *bounds.Min > *bounds.Max {
+			return input, errBadQuery
+		}
+		if bounds.Min != nil || bounds.Max != nil {
+			if input.Ranges == nil {
+				input.Ranges = make(map[string]dto.Range)
+			}
+			input.Ranges[name] = bounds
+		}
+	}
+
+	// Every registered analyzer is filterable by its name
+	for _, analyzer := range analysis.Default().Analyzers() {
+		filter, err := parsePropertyFilter(c, analyzer)
+		if err != nil {
+			return input, err
+		}
+		if filter.Equals == nil && filter.Min == nil && filter.Max == nil {
+			continue
+		}
+		if input.Properties == nil {
+			input.Properties = make(map[string]dto.PropertyFilter)
+		}
+		input.Properties[analyzer.Name()] = filter
+	}
+
+	// Parse tag, which may be repeated to require several tags
+	for _, tag := range c.QueryArray("tag") {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return input, errBadQuery
+		}
+		input.Tags = append(input.Tags, tag)
+	}
+
+	// Parse metadata.<key>=<value> pairs
+	for param, values := range c.Request.URL.Query() {
+		key, ok := strings.CutPrefix(param, "metadata.")
+		if !ok {
+			continue
+		}
+		if key == "" || len(values) != 1 {
+			return input, errBadQuery
+		}
+		if input.Metadata == nil {
+			input.Metadata = make(map[string]string)
+		}
+		input.Metadata[key] = values[0]
+	}
+
+	return input, nil
+}
+
+func (h *StringsHandler) FilterByCriteria(c *gin.Context) {
+	input, err := parseFilterCriteria(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Bad query"})
+		return
+	}
+
+	etag, answered := h.listETag(c)
+	if answered {
+		return
+	}
+
+	response, err := h.stringsService.FilterByCriteria(c.Request.Context(), collectionName(c), input)
+	if err != nil {
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve strings")
+		return
+	}
+
+	setValidators(c, etag)
+	omitExcludedFromList(c, response.Data)
+	c.JSON(http.StatusOK, response)
+}
+
+// Bounds of the top query parameter of Stats
+const (
+	defaultStatsTop = 10
+	maxStatsTop     = 100
+)
+
+// Stats summarises the collection's live strings: their count and the top
+// most frequent n-grams of every counted size
+func (h *StringsHandler) Stats(c *gin.Context) {
+	top := defaultStatsTop
+	if raw := c.Query("top"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 || parsed > maxStatsTop {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid top; must be between 1 and 100"})
+			return
+		}
+		top = parsed
+	}
+
+	etag, answered := h.listETag(c)
+	if answered {
+		return
+	}
+
+	response, err := h.stringsService.Stats(c.Request.Context(), collectionName(c), top)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve strings"})
+		middleware.AbortServerError(c, h.logger, err, "Failed to compute stats")
+		return
+	}
+
+	setValidators(c, etag)
+	c.JSON(http.StatusOK, response)
+}
+
+// ExplainFilter shows the plan PostgreSQL picks for the filter given in the
+// query string. The collection defaults to the default collection and
+// analyze=true executes the query to report actual timings.
+func (h *StringsHandler) ExplainFilter(c *gin.Context) {
+	input, err := parseFilterCriteria(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"message": "Bad query"})
+		return
+	}
+
+	analyze := false
+	if raw := c.Query("analyze"); raw != "" {
+		analyze, err = strconv.ParseBool(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Bad query"})
+			return
+		}
+	}
+
+	collection := c.DefaultQuery("collection", services.DefaultCollection)
+	response, err := h.stringsService.ExplainFilter(c.Request.Context(), collection, input, analyze)
+	if err != nil {
+		middleware.AbortServerError(c, h.logger, err, "Failed to explain filter")
 		return
 	}
 
@@ -158,7 +608,12 @@ func (h *StringsHandler) FilterByNaturalLanguage(c *gin.Context) {
 		Query: query,
 	}
 
-	response, err := h.stringsService.FilterByNaturalLanguage(input)
+	etag, answered := h.listETag(c)
+	if answered {
+		return
+	}
+
+	response, err := h.stringsService.FilterByNaturalLanguage(c.Request.Context(), collectionName(c), input)
 	if err != nil {
 		// Check if it's a parsing error (400) or validation error (422)
 		if strings.Contains(err.Error(), "unable to parse") {
@@ -169,30 +624,97 @@ func (h *StringsHandler) FilterByNaturalLanguage(c *gin.Context) {
 			c.JSON(http.StatusUnprocessableEntity, gin.H{"message": "Query parsed but resulted in conflicting filters"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve strings"})
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve strings")
 		return
 	}
 
+	setValidators(c, etag)
+	omitExcludedFromList(c, response.Data)
 	c.JSON(http.StatusOK, response)
 }
 
 func (h *StringsHandler) DeleteStringEntry(c *gin.Context) {
 	// get the string value
 	value := c.Param("string_value")
+	collection := collectionName(c)
 
 	// pass down to service
-	err := h.stringsService.DeleteStringEntry(value)
+	err := h.stringsService.DeleteStringEntry(c.Request.Context(), collection, value, ifMatch(c))
 	if err != nil {
+		if preconditionFailed(c, err) {
+			return
+		}
 		if strings.Contains(err.Error(), "not found") {
 			c.JSON(http.StatusNotFound, gin.H{
 				"message": "String does not exist in the system",
 			})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to delete string",
-		})
+		middleware.AbortServerError(c, h.logger, err, "Failed to delete string")
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (h *StringsHandler) DeleteStringEntryById(c *gin.Context) {
+	id := c.Param("id")
+	collection := collectionName(c)
+
+	err := h.stringsService.DeleteStringEntryById(c.Request.Context(), collection, id, ifMatch(c))
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid id") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid id; must be a SHA-256 hash"})
+			return
+		}
+		if preconditionFailed(c, err) {
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{
+				"message": "String does not exist in the system",
+			})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to delete string")
 		return
 	}
 	c.Status(http.StatusNoContent)
 }
+
+func (h *StringsHandler) ListTrash(c *gin.Context) {
+	etag, answered := h.listETag(c)
+	if answered {
+		return
+	}
+
+	response, err := h.stringsService.ListTrash(c.Request.Context(), collectionName(c))
+	if err != nil {
+		middleware.AbortServerError(c, h.logger, err, "Failed to retrieve trash")
+		return
+	}
+
+	setValidators(c, etag)
+	omitExcludedFromList(c, response.Data)
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *StringsHandler) RestoreStringEntry(c *gin.Context) {
+	id := c.Param("id")
+
+	response, err := h.stringsService.RestoreStringEntry(c.Request.Context(), collectionName(c), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "invalid id") {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid id; must be a SHA-256 hash"})
+			return
+		}
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": "String is not in the trash"})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to restore string")
+		return
+	}
+
+	omitExcluded(includedGroups(c), &response.Properties)
+	c.JSON(http.StatusOK, response)
+}
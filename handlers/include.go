@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"slices"
+	"strings"
+	"task_one/analysis"
+	"task_one/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+// includedGroups reads the comma separated property groups of ?include=,
+// such as ngrams. Unknown groups are ignored.
+func includedGroups(c *gin.Context) []string {
+	var groups []string
+	for _, raw := range c.QueryArray("include") {
+		for group := range strings.SplitSeq(raw, ",") {
+			if group = strings.TrimSpace(group); group != "" {
+				groups = append(groups, group)
+			}
+		}
+	}
+	return groups
+}
+
+// omitExcluded drops the registered properties the response should not
+// carry: those of a group the request did not include, and those left by
+// analyzers that are no longer registered
+func omitExcluded(groups []string, properties *dto.StringProperties) {
+	for name := range properties.Registered {
+		analyzer, ok := analysis.Default().Lookup(name)
+		if !ok {
+			delete(properties.Registered, name)
+			continue
+		}
+		if group := analysis.GroupOf(analyzer); group != "" && !slices.Contains(groups, group) {
+			delete(properties.Registered, name)
+		}
+	}
+}
+
+// omitExcludedFromList applies omitExcluded to every entry of a listing
+func omitExcludedFromList(c *gin.Context, entries []dto.GetStringByValueResponse) {
+	groups := includedGroups(c)
+	for i := range entries {
+		omitExcluded(groups, &entries[i].Properties)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"task_one/middleware"
+	"task_one/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRecomputeBatchSize keeps a single batch transaction short
+const maxRecomputeBatchSize = 10000
+
+type RecomputeHandler struct {
+	job      *services.RecomputeJob
+	defaults services.RecomputeOptions
+	logger   *slog.Logger
+}
+
+func NewRecomputeHandler(job *services.RecomputeJob, defaults services.RecomputeOptions, logger *slog.Logger) *RecomputeHandler {
+	return &RecomputeHandler{
+		job:      job,
+		defaults: defaults,
+		logger:   logger,
+	}
+}
+
+// StartRecompute starts re-analyzing entries stored by older analyzer
+// versions. batch_size and pause override the configured throttling.
+func (h *RecomputeHandler) StartRecompute(c *gin.Context) {
+	options := h.defaults
+	if raw := c.Query("batch_size"); raw != "" {
+		val, err := strconv.Atoi(raw)
+		if err != nil || val <= 0 || val > maxRecomputeBatchSize {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "batch_size must be between 1 and " + strconv.Itoa(maxRecomputeBatchSize)})
+			return
+		}
+		options.BatchSize = val
+	}
+	if raw := c.Query("pause"); raw != "" {
+		val, err := time.ParseDuration(raw)
+		if err != nil || val < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"message": "pause must be a non-negative duration such as 250ms"})
+			return
+		}
+		options.Pause = val
+	}
+
+	status, err := h.job.Start(options)
+	if err != nil {
+		if strings.Contains(err.Error(), "conflict") {
+			c.JSON(http.StatusConflict, gin.H{"message": "A recompute is already running"})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to start recompute")
+		return
+	}
+
+	c.Header("Location", "/admin/recompute")
+	c.JSON(http.StatusAccepted, status)
+}
+
+// GetRecompute reports the progress of the running or latest recompute
+func (h *RecomputeHandler) GetRecompute(c *gin.Context) {
+	c.JSON(http.StatusOK, h.job.Status())
+}
+
+// CancelRecompute stops the running recompute; it can be started again later
+// and carries on with the entries that are still stale
+func (h *RecomputeHandler) CancelRecompute(c *gin.Context) {
+	status, err := h.job.Cancel()
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			c.JSON(http.StatusNotFound, gin.H{"message": "No recompute is running"})
+			return
+		}
+		middleware.AbortServerError(c, h.logger, err, "Failed to cancel recompute")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,19 @@
+package initializers
+
+import (
+	"log/slog"
+	"task_one/analysis"
+	"task_one/config"
+)
+
+// RegisterAnalyzers registers the analyzers that depend on configuration.
+// It must run once, before the first string is analyzed.
+func RegisterAnalyzers(conf *config.Config) error {
+	if err := analysis.RegisterNGrams(conf.NGramSizes); err != nil {
+		return err
+	}
+	if len(conf.NGramSizes) > 0 {
+		slog.Info("Counting n-grams", slog.Any("sizes", conf.NGramSizes))
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package initializers
+
+import (
+	"fmt"
+	"log/slog"
+	"task_one/cache"
+	"task_one/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache backends selectable with CACHE_BACKEND
+const (
+	CacheBackendNone   = "none"
+	CacheBackendMemory = "memory"
+	CacheBackendRedis  = "redis"
+)
+
+// cacheKeyPrefix keeps this service's keys apart on a shared Redis
+const cacheKeyPrefix = TracingServiceName + ":"
+
+// NewCache builds the repository cache. It returns nil when caching is
+// disabled.
+func NewCache(conf *config.Config) (cache.Cache, error) {
+	switch conf.CacheBackend {
+	case CacheBackendNone, "":
+		return nil, nil
+	case CacheBackendMemory:
+		slog.Info("Caching lookups in memory", slog.Int("size", conf.CacheSize), slog.Duration("ttl", conf.CacheTTL))
+		return cache.NewMemory(conf.CacheSize), nil
+	case CacheBackendRedis:
+		options, err := redis.ParseURL(conf.CacheRedisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_REDIS_URL: %v", err)
+		}
+		slog.Info("Caching lookups in Redis", slog.String("addr", options.Addr), slog.Duration("ttl", conf.CacheTTL))
+		return cache.NewRedis(redis.NewClient(options), cacheKeyPrefix), nil
+	default:
+		return nil, fmt.Errorf("unknown CACHE_BACKEND %q", conf.CacheBackend)
+	}
+}
@@ -2,16 +2,17 @@ package initializers
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
 	"os"
 	"path"
 	"strings"
 	"task_one/config"
-	"task_one/models"
+	"task_one/logging"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	gormtracing "gorm.io/plugin/opentelemetry/tracing"
 )
 
 func ConnectDB(conf *config.Config) (*gorm.DB, error) {
@@ -23,33 +24,69 @@ func ConnectDB(conf *config.Config) (*gorm.DB, error) {
 		return nil, fmt.Errorf("no database DSN provided; set DB_URL or DATABASE_URL")
 	}
 	// First attempt to connect using the provided DSN (use the DB the user specified)
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	gormConfig := &gorm.Config{Logger: logging.Gorm(slog.Default())}
+	db, err := gorm.Open(postgres.Open(dsn), gormConfig)
 	if err != nil {
 		// Check if the error is because the database doesn't exist
 		if strings.Contains(err.Error(), "does not exist") {
-			log.Printf("Database does not exist, attempting to create it: %v", err)
+			slog.Warn("Database does not exist, attempting to create it", slog.Any("error", err))
 			// Try to create the database named in the DSN
 			if createErr := createDatabase(dsn); createErr != nil {
-				log.Printf("Failed to create database: %v", createErr)
+				slog.Error("Failed to create database", slog.Any("error", createErr))
 				return nil, fmt.Errorf("failed to connect to database and failed to create it: %v", err)
 			}
 
 			// Try to connect again after creating the database
-			db, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+			db, err = gorm.Open(postgres.Open(dsn), gormConfig)
 			if err != nil {
-				log.Printf("Failed to connect to database after creation: %v", err)
+				slog.Error("Failed to connect to database after creation", slog.Any("error", err))
 				return nil, err
 			}
 		} else {
-			log.Printf("Unable to connect to database: %v\n", err)
+			slog.Error("Unable to connect to database", slog.Any("error", err))
 			return nil, err
 		}
 	}
 
-	log.Println("Successfully connected to PostgresDB (via GORM)")
+	// Trace every query as a child of the request span. Values are left out
+	// so submitted strings do not end up in the tracing backend.
+	err = db.Use(gormtracing.NewPlugin(
+		gormtracing.WithDBSystem("postgresql"),
+		gormtracing.WithoutQueryVariables(),
+		gormtracing.WithoutMetrics(),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to enable query tracing: %v", err)
+	}
+
+	// Size the pool so the service cannot exhaust the database's connections
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	sqlDB.SetMaxOpenConns(conf.DBMaxOpenConns)
+	sqlDB.SetMaxIdleConns(conf.DBMaxIdleConns)
+	sqlDB.SetConnMaxLifetime(conf.DBConnMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(conf.DBConnMaxIdleTime)
+
+	slog.Info("Successfully connected to PostgresDB (via GORM)")
 	return db, nil
 }
 
+// CloseDB closes the connection pool behind db
+func CloseDB(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		slog.Error("Failed to access connection pool", slog.Any("error", err))
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		slog.Error("Failed to close connection pool", slog.Any("error", err))
+		return
+	}
+	slog.Info("Closed database connection pool")
+}
+
 func createDatabase(dsn string) error {
 	// Parse the DSN to create admin DSN
 	parsedURL, err := url.Parse(dsn)
@@ -63,10 +100,11 @@ func createDatabase(dsn string) error {
 	adminDSN := adminURL.String()
 
 	// Connect to admin database
-	adminDB, err := gorm.Open(postgres.Open(adminDSN), &gorm.Config{})
+	adminDB, err := gorm.Open(postgres.Open(adminDSN), &gorm.Config{Logger: logging.Gorm(slog.Default())})
 	if err != nil {
 		return fmt.Errorf("failed to connect to admin database: %v", err)
 	}
+	defer CloseDB(adminDB)
 
 	// Determine the database name from the DSN path (use the last path segment)
 	dbPath := parsedURL.Path
@@ -86,16 +124,6 @@ func createDatabase(dsn string) error {
 		}
 	}
 
-	log.Printf("Successfully created database: %s", dbName)
-	return nil
-}
-
-
-func DoMigrate(db *gorm.DB) error {
-	err := db.AutoMigrate(&models.StringEntry{})
-	if err != nil {
-		log.Println("Failed to perform migrations")
-		return err
-	}
+	slog.Info("Successfully created database", slog.String("database", dbName))
 	return nil
 }
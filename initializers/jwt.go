@@ -0,0 +1,36 @@
+package initializers
+
+import (
+	"fmt"
+	"log/slog"
+	"task_one/auth"
+	"task_one/config"
+	"time"
+)
+
+// NewJWTVerifier builds the bearer token verifier from configuration. It
+// returns nil when no JWKS is configured, which disables bearer tokens.
+func NewJWTVerifier(conf *config.Config) (*auth.JWTVerifier, error) {
+	if conf.JWTJWKS == "" {
+		return nil, nil
+	}
+
+	claimScopes, err := auth.ParseClaimScopes(conf.JWTClaimScopes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWT_CLAIM_SCOPES: %v", err)
+	}
+
+	keys, err := auth.NewKeySource(conf.JWTJWKS, conf.JWTJWKSRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("Accepting bearer tokens", slog.String("jwks", conf.JWTJWKS))
+	return auth.NewJWTVerifier(keys, auth.JWTConfig{
+		Issuer:      conf.JWTIssuer,
+		Audience:    conf.JWTAudience,
+		ScopeClaim:  conf.JWTScopeClaim,
+		ClaimScopes: claimScopes,
+		Leeway:      30 * time.Second,
+	}), nil
+}
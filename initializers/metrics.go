@@ -0,0 +1,27 @@
+package initializers
+
+import (
+	"task_one/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"gorm.io/gorm"
+)
+
+// NewMetrics creates the registry served on /metrics with the service's
+// instruments, the connection pool stats of db and the Go runtime collectors
+func NewMetrics(db *gorm.DB) (*prometheus.Registry, *metrics.Metrics, error) {
+	registry := prometheus.NewRegistry()
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, nil, err
+	}
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		collectors.NewDBStatsCollector(sqlDB, "postgres"),
+	)
+
+	return registry, metrics.New(registry), nil
+}
@@ -0,0 +1,43 @@
+package initializers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"task_one/migrations"
+
+	"gorm.io/gorm"
+)
+
+// EnsureSchema makes sure the database schema is at the version this build
+// expects. Pending migrations are applied when migrateOnStart is set;
+// otherwise a schema that is behind is an error, so the server never runs
+// against tables it does not understand.
+func EnsureSchema(ctx context.Context, db *gorm.DB, migrateOnStart bool) error {
+	migrator := migrations.New(db)
+
+	if migrateOnStart {
+		applied, err := migrator.Up(ctx)
+		for _, migration := range applied {
+			slog.Info("Applied migration", slog.Int("version", migration.Version), slog.String("name", migration.Name))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	version, err := migrator.Current(ctx)
+	if err != nil {
+		return err
+	}
+	latest := migrations.Latest()
+	switch {
+	case version < latest:
+		return fmt.Errorf("database schema is at version %d but this build needs %d; run the migrate up command", version, latest)
+	case version > latest:
+		// Migrations are written to be backward compatible, so an older build
+		// may keep serving during a rollout or rollback
+		slog.Warn("Database schema is newer than this build", slog.Int("version", version), slog.Int("expected", latest))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package initializers
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"task_one/buildinfo"
+	"task_one/config"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+)
+
+// TracingServiceName names this service in exported spans
+const TracingServiceName = "string-analyzer"
+
+// Tracing exporters selectable with TRACING_EXPORTER
+const (
+	TracingExporterNone   = "none"
+	TracingExporterOTLP   = "otlp"
+	TracingExporterStdout = "stdout"
+)
+
+// NewTracerProvider installs the global tracer provider and W3C trace
+// context propagation. The returned function flushes buffered spans and must
+// be called on shutdown. With the none exporter spans are still propagated
+// but never recorded.
+func NewTracerProvider(conf *config.Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch conf.TracingExporter {
+	case TracingExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case TracingExporterOTLP:
+		exporter, err = otlptracehttp.New(context.Background(), otlptracehttp.WithEndpointURL(conf.TracingOTLPEndpoint))
+	case TracingExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unknown TRACING_EXPORTER %q", conf.TracingExporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s span exporter: %v", conf.TracingExporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(TracingServiceName),
+		semconv.ServiceVersion(buildinfo.Get().GitSHA),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(conf.TracingSampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	slog.Info("Exporting traces", slog.String("exporter", conf.TracingExporter), slog.Float64("sample_ratio", conf.TracingSampleRatio))
+	return provider.Shutdown, nil
+}
@@ -0,0 +1,23 @@
+package initializers
+
+import (
+	"log/slog"
+	"task_one/config"
+	"task_one/services"
+)
+
+// NewValuePolicy builds the limits applied to submitted strings from configuration
+func NewValuePolicy(conf *config.Config) services.ValuePolicy {
+	controlChars := conf.ControlChars
+	if controlChars != services.ControlCharsReject && controlChars != services.ControlCharsStrip {
+		slog.Warn("Unknown CONTROL_CHARS policy, rejecting control characters", slog.String("policy", controlChars))
+		controlChars = services.ControlCharsReject
+	}
+
+	return services.ValuePolicy{
+		MaxBytes:     conf.MaxValueBytes,
+		MaxRunes:     conf.MaxValueRunes,
+		MaxWords:     conf.MaxValueWords,
+		ControlChars: controlChars,
+	}
+}
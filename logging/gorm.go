@@ -0,0 +1,26 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold is the duration above which queries are logged as slow
+const slowQueryThreshold = 200 * time.Millisecond
+
+// Gorm adapts logger for GORM. Every statement is logged when debug is
+// enabled; otherwise only slow and failed statements are.
+func Gorm(logger *slog.Logger) gormlogger.Interface {
+	level := gormlogger.Warn
+	if logger.Enabled(context.Background(), slog.LevelDebug) {
+		level = gormlogger.Info
+	}
+	return gormlogger.NewSlogLogger(logger, gormlogger.Config{
+		SlowThreshold:             slowQueryThreshold,
+		IgnoreRecordNotFoundError: true,
+		LogLevel:                  level,
+	})
+}
@@ -0,0 +1,143 @@
+// Package metrics defines the Prometheus instruments of the service. All
+// instruments are registered on a caller supplied registry so tests can use
+// a fresh one. Methods are safe to call on a nil *Metrics, which records nothing.
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "string_analyzer"
+
+type Metrics struct {
+	httpRequests      *prometheus.CounterVec
+	httpDuration      *prometheus.HistogramVec
+	analysisDuration  *prometheus.HistogramVec
+	inputSize         prometheus.Histogram
+	nlpParses         *prometheus.CounterVec
+	repositoryQueries *prometheus.HistogramVec
+	cacheLookups      *prometheus.CounterVec
+}
+
+// New creates the instruments and registers them on registerer
+func New(registerer prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		analysisDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "analysis_duration_seconds",
+			Help:      "Time spent computing each string property.",
+			Buckets:   prometheus.ExponentialBuckets(1e-6, 4, 10),
+		}, []string{"analyzer"}),
+		inputSize: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "analysis_input_bytes",
+			Help:      "Size of strings submitted for analysis.",
+			Buckets:   prometheus.ExponentialBuckets(16, 4, 8),
+		}),
+		nlpParses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "nlp_parses_total",
+			Help:      "Natural language parse outcomes by matched rule.",
+		}, []string{"rule", "outcome"}),
+		repositoryQueries: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "repository_query_duration_seconds",
+			Help:      "Repository call latency by repository, method and outcome.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"repository", "method", "outcome"}),
+		cacheLookups: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_lookups_total",
+			Help:      "Repository cache lookups by cached call and result.",
+		}, []string{"cache", "result"}),
+	}
+
+	registerer.MustRegister(
+		m.httpRequests,
+		m.httpDuration,
+		m.analysisDuration,
+		m.inputSize,
+		m.nlpParses,
+		m.repositoryQueries,
+		m.cacheLookups,
+	)
+	return m
+}
+
+func (m *Metrics) ObserveHTTPRequest(method, route, status string, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(method, route, status).Inc()
+	m.httpDuration.WithLabelValues(method, route, status).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) ObserveAnalysis(analyzer string, elapsed time.Duration) {
+	if m == nil {
+		return
+	}
+	m.analysisDuration.WithLabelValues(analyzer).Observe(elapsed.Seconds())
+}
+
+func (m *Metrics) ObserveInputSize(bytes int) {
+	if m == nil {
+		return
+	}
+	m.inputSize.Observe(float64(bytes))
+}
+
+// NLP parse outcomes
+const (
+	ParseMatched = "matched"
+	ParseNoMatch = "no_match"
+	ParseFailure = "failure"
+)
+
+func (m *Metrics) ObserveParse(rule, outcome string) {
+	if m == nil {
+		return
+	}
+	m.nlpParses.WithLabelValues(rule, outcome).Inc()
+}
+
+// Repository call outcomes
+const (
+	QueryOK       = "ok"
+	QueryError    = "error"
+	QueryCanceled = "canceled"
+	QueryTimeout  = "timeout"
+)
+
+func (m *Metrics) ObserveQuery(repository, method string, elapsed time.Duration, outcome string) {
+	if m == nil {
+		return
+	}
+	m.repositoryQueries.WithLabelValues(repository, method, outcome).Observe(elapsed.Seconds())
+}
+
+// Cache lookup results
+const (
+	CacheHit   = "hit"
+	CacheMiss  = "miss"
+	CacheError = "error"
+)
+
+func (m *Metrics) ObserveCacheLookup(cache, result string) {
+	if m == nil {
+		return
+	}
+	m.cacheLookups.WithLabelValues(cache, result).Inc()
+}
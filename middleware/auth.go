@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"strings"
+	"task_one/auth"
+	"task_one/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHeader carries the API key of a request
+const APIKeyHeader = "X-API-Key"
+
+// anonymous is the principal of every request when authentication is disabled
+var anonymous = &auth.Principal{
+	ID:     "anonymous",
+	Name:   "anonymous",
+	Scopes: []string{auth.ScopeAdmin},
+}
+
+// Authenticate resolves the bearer token or API key of the request into a
+// principal and rejects requests without valid credentials. Bearer tokens are
+// only accepted when jwtVerifier is set. When enabled is false every request
+// is treated as an anonymous admin.
+func Authenticate(apiKeyService services.APIKeyService, jwtVerifier *auth.JWTVerifier, enabled bool, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !enabled {
+			auth.SetPrincipal(c, anonymous)
+			c.Next()
+			return
+		}
+
+		if token, ok := bearerToken(c); ok && jwtVerifier != nil {
+			principal, err := jwtVerifier.Verify(token)
+			if err != nil {
+				c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid bearer token"})
+				return
+			}
+			auth.SetPrincipal(c, principal)
+			c.Next()
+			return
+		}
+
+		rawKey := strings.TrimSpace(c.GetHeader(APIKeyHeader))
+		if rawKey == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Missing API key or bearer token"})
+			return
+		}
+
+		principal, err := apiKeyService.Authenticate(c.Request.Context(), rawKey)
+		if err != nil {
+			if strings.Contains(err.Error(), "unauthorized") {
+				c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Invalid API key"})
+				return
+			}
+			AbortServerError(c, logger, err, "Failed to authenticate request")
+			return
+		}
+
+		auth.SetPrincipal(c, principal)
+		c.Next()
+	}
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// RequireScope rejects requests whose principal was not granted scope
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := auth.GetPrincipal(c)
+		if principal == nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"message": "Missing API key or bearer token"})
+			return
+		}
+		if !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"message": "Missing required scope " + scope})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"task_one/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StatusClientClosedRequest is the non standard status recorded when the
+// client disconnects before the response is ready
+const StatusClientClosedRequest = 499
+
+// AbortServerError answers a failed service call. Work abandoned because the
+// client went away or the query timed out is answered with 499 or 503;
+// anything else is logged with the request's ID and answered with 500 and
+// message, keeping the underlying cause out of the response.
+func AbortServerError(c *gin.Context, logger *slog.Logger, err error, message string) {
+	switch {
+	case errors.Is(err, services.ErrCanceled):
+		logger.InfoContext(c.Request.Context(), "Request canceled by the client", slog.String("route", c.FullPath()))
+		c.AbortWithStatus(StatusClientClosedRequest)
+	case errors.Is(err, services.ErrTimeout):
+		logger.WarnContext(c.Request.Context(), "Request timed out", slog.String("route", c.FullPath()), slog.Any("error", err))
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"message": "Request timed out"})
+	default:
+		logger.ErrorContext(c.Request.Context(), message, slog.String("route", c.FullPath()), slog.Any("error", err))
+		c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"message": message})
+	}
+}
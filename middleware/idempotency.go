@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"task_one/dto"
+	"task_one/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyKeyHeader lets a client retry a request without repeating its
+// effect
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// maxIdempotencyKeyLength leaves room for a UUID with a prefix while keeping
+// the stored key small
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the response headers stored alongside the body
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// Idempotency replays the stored response when a request repeats an
+// Idempotency-Key the client already used. Requests without the header pass
+// through untouched. Responses the client should retry, such as 5xx and 429,
+// are not stored.
+func Idempotency(idempotencyService services.IdempotencyService, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if !validIdempotencyKey(key) {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "Invalid Idempotency-Key header"})
+			return
+		}
+
+		// The body is part of what makes two requests the same, so read it
+		// here and hand the handler a fresh copy
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"message": "Request body too large"})
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"message": "Failed to read request body"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		reservation, stored, err := idempotencyService.Begin(c.Request.Context(), ClientKey(c), key, requestFingerprint(c.Request, body))
+		if err != nil {
+			if strings.Contains(err.Error(), "conflict") {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"message": "A request with this Idempotency-Key is still in progress"})
+				return
+			}
+			if strings.Contains(err.Error(), "invalid") {
+				c.AbortWithStatusJSON(http.StatusUnprocessableEntity, gin.H{"message": "Idempotency-Key was already used for a different request"})
+				return
+			}
+			AbortServerError(c, logger, err, "Failed to check idempotency key")
+			return
+		}
+		if stored != nil {
+			for name, value := range stored.Headers {
+				c.Header(name, value)
+			}
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.StatusCode, stored.Headers["Content-Type"], stored.Body)
+			c.Abort()
+			return
+		}
+
+		// The outcome must be recorded even when the client has gone away. A
+		// panicking handler leaves no outcome, so the key is given back before
+		// the panic reaches Recovery.
+		ctx := context.WithoutCancel(c.Request.Context())
+		finished := false
+		defer func() {
+			if !finished {
+				idempotencyService.Release(ctx, *reservation)
+			}
+		}()
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		status := c.Writer.Status()
+		if retryableStatus(status) {
+			return
+		}
+		finished = true
+		response := dto.StoredResponse{StatusCode: status, Headers: map[string]string{}, Body: recorder.body.Bytes()}
+		for _, name := range replayedHeaders {
+			if value := c.Writer.Header().Get(name); value != "" {
+				response.Headers[name] = value
+			}
+		}
+		idempotencyService.Complete(ctx, *reservation, response)
+	}
+}
+
+func validIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for _, r := range key {
+		if r < 0x20 || r > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestFingerprint identifies a request by method, path, query string and
+// body
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.RequestURI() + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// retryableStatus reports responses that say nothing final about the
+// request, so repeating it must run it again
+func retryableStatus(status int) bool {
+	return status >= http.StatusInternalServerError ||
+		status == http.StatusTooManyRequests ||
+		status == http.StatusRequestTimeout ||
+		status == StatusClientClosedRequest
+}
+
+// bodyRecorder copies the response body as it is written
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(data string) (int, error) {
+	w.body.WriteString(data)
+	return w.ResponseWriter.WriteString(data)
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"task_one/services"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DailyCreationQuota charges each successful creation against the client's
+// daily quota and rejects requests once it is used up
+func DailyCreationQuota(quotaService services.QuotaService, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		clientKey := ClientKey(c)
+		status, err := quotaService.ConsumeDailyCreation(c.Request.Context(), clientKey)
+		if status != nil {
+			c.Header("X-Quota-Limit", strconv.FormatInt(status.Limit, 10))
+			c.Header("X-Quota-Remaining", strconv.FormatInt(status.Remaining, 10))
+			c.Header("X-Quota-Reset", strconv.FormatInt(status.ResetAt.Unix(), 10))
+		}
+		if err != nil {
+			if strings.Contains(err.Error(), "quota exceeded") {
+				retryAfter := int(math.Ceil(time.Until(status.ResetAt).Seconds()))
+				c.Header("Retry-After", strconv.Itoa(retryAfter))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"message": "Daily creation quota exceeded"})
+				return
+			}
+			AbortServerError(c, logger, err, "Failed to check quota")
+			return
+		}
+
+		c.Next()
+
+		// Only strings that were actually created count against the quota. The
+		// refund must land even when the client has gone away.
+		if status != nil && c.Writer.Status() != http.StatusCreated {
+			quotaService.RefundDailyCreation(context.WithoutCancel(c.Request.Context()), clientKey, status.Day)
+		}
+	}
+}
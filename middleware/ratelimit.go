@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"task_one/auth"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// idleBucketTTL is how long an untouched bucket is kept before being swept
+const idleBucketTTL = 10 * time.Minute
+
+// RateLimiter is an in-memory token bucket limiter keyed by client. Each
+// route group gets its own limiter so limits can differ per group.
+type RateLimiter struct {
+	rate  float64 // tokens added per second
+	burst float64 // bucket capacity
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewRateLimiter allows rps requests per second per client with bursts of
+// up to burst requests. It returns nil, which disables limiting, when rps is
+// not positive.
+func NewRateLimiter(rps float64, burst int) *RateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = int(math.Max(1, math.Ceil(rps)))
+	}
+	return &RateLimiter{
+		rate:      rps,
+		burst:     float64(burst),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+// take removes a token from the client's bucket. It returns whether the
+// request is allowed, the tokens left and how long until the next token.
+func (l *RateLimiter) take(key string, now time.Time) (bool, float64, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > idleBucketTTL {
+		for k, b := range l.buckets {
+			if now.Sub(b.updated) > idleBucketTTL {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.updated).Seconds()*l.rate)
+	b.updated = now
+
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+		return false, b.tokens, wait
+	}
+	b.tokens--
+	return true, b.tokens, l.untilFull(b.tokens)
+}
+
+func (l *RateLimiter) untilFull(tokens float64) time.Duration {
+	return time.Duration((l.burst - tokens) / l.rate * float64(time.Second))
+}
+
+// RateLimit enforces limiter on the route group and reports the state of the
+// client's bucket in X-RateLimit-* headers. A nil limiter lets everything through.
+func RateLimit(limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		allowed, remaining, wait := limiter.take(ClientKey(c), time.Now())
+		c.Header("X-RateLimit-Limit", strconv.Itoa(int(limiter.burst)))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(int(math.Max(0, math.Floor(remaining)))))
+
+		if !allowed {
+			retryAfter := strconv.Itoa(int(math.Ceil(wait.Seconds())))
+			c.Header("X-RateLimit-Reset", retryAfter)
+			c.Header("Retry-After", retryAfter)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"message": "Rate limit exceeded"})
+			return
+		}
+		c.Header("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		c.Next()
+	}
+}
+
+// ClientKey identifies the caller for rate limiting and quotas: the
+// authenticated principal when there is one, the client IP otherwise
+func ClientKey(c *gin.Context) string {
+	if principal := auth.GetPrincipal(c); principal != nil && principal != anonymous {
+		return principal.ID
+	}
+	return "ip:" + c.ClientIP()
+}
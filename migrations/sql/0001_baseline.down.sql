@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS client_quotas;
+DROP TABLE IF EXISTS api_keys;
+DROP TABLE IF EXISTS string_entries;
+DROP TABLE IF EXISTS collections;
@@ -0,0 +1,74 @@
+-- Baseline schema. Written to be idempotent so databases previously managed
+-- by GORM's AutoMigrate, at any earlier shape, are adopted in place.
+
+CREATE TABLE IF NOT EXISTS collections (
+    name       text PRIMARY KEY,
+    created_at timestamptz
+);
+
+INSERT INTO collections (name, created_at) VALUES ('default', now())
+ON CONFLICT DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS string_entries (
+    collection              text        NOT NULL DEFAULT 'default',
+    id                      text        NOT NULL,
+    value                   text        NOT NULL,
+    length                  bigint      NOT NULL,
+    is_palindrome           boolean     NOT NULL,
+    unique_characters       bigint      NOT NULL,
+    word_count              bigint      NOT NULL,
+    sha256_hash             text        NOT NULL,
+    character_frequency_map jsonb       NOT NULL,
+    tags                    jsonb       NOT NULL DEFAULT '[]',
+    metadata                jsonb       NOT NULL DEFAULT '{}',
+    created_by              text        NOT NULL DEFAULT '',
+    created_at              timestamptz,
+    deleted_at              timestamptz,
+    PRIMARY KEY (collection, id)
+);
+
+-- Columns added after the first release
+ALTER TABLE string_entries
+    ADD COLUMN IF NOT EXISTS collection text        NOT NULL DEFAULT 'default',
+    ADD COLUMN IF NOT EXISTS tags       jsonb       NOT NULL DEFAULT '[]',
+    ADD COLUMN IF NOT EXISTS metadata   jsonb       NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS created_by text        NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS deleted_at timestamptz;
+
+-- Tables created before collections existed are keyed on id alone
+DO $$
+BEGIN
+    IF (SELECT count(*)
+        FROM information_schema.table_constraints tc
+        JOIN information_schema.key_column_usage kcu
+            ON tc.constraint_name = kcu.constraint_name AND tc.table_schema = kcu.table_schema
+        WHERE tc.table_name = 'string_entries' AND tc.constraint_type = 'PRIMARY KEY'
+            AND tc.table_schema = current_schema()) = 1 THEN
+        ALTER TABLE string_entries
+            DROP CONSTRAINT string_entries_pkey,
+            ADD PRIMARY KEY (collection, id);
+    END IF;
+END
+$$;
+
+CREATE INDEX IF NOT EXISTS idx_string_entries_deleted_at ON string_entries (deleted_at);
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id           text PRIMARY KEY,
+    name         text  NOT NULL,
+    prefix       text  NOT NULL,
+    key_hash     text  NOT NULL,
+    scopes       jsonb NOT NULL,
+    created_at   timestamptz,
+    last_used_at timestamptz,
+    revoked_at   timestamptz
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys (key_hash);
+
+CREATE TABLE IF NOT EXISTS client_quotas (
+    client_key text   NOT NULL,
+    day        date   NOT NULL,
+    count      bigint NOT NULL DEFAULT 0,
+    PRIMARY KEY (client_key, day)
+);
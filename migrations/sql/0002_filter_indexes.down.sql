@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_string_entries_sha256_hash;
+DROP INDEX IF EXISTS idx_string_entries_tags;
+DROP INDEX IF EXISTS idx_string_entries_character_frequency_map;
+DROP INDEX IF EXISTS idx_string_entries_is_palindrome;
+DROP INDEX IF EXISTS idx_string_entries_word_count;
+DROP INDEX IF EXISTS idx_string_entries_length;
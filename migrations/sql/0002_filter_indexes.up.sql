@@ -0,0 +1,20 @@
+-- Filters always scope by collection and skip the trash, so the B-tree
+-- indexes lead with collection and only cover live rows
+CREATE INDEX IF NOT EXISTS idx_string_entries_length
+    ON string_entries (collection, length) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_string_entries_word_count
+    ON string_entries (collection, word_count) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_string_entries_is_palindrome
+    ON string_entries (collection, is_palindrome) WHERE deleted_at IS NULL;
+
+-- contains_character is a jsonpath exists() test (@@) and tag filters use
+-- containment (@>), both served by the default jsonb_ops GIN operator class
+CREATE INDEX IF NOT EXISTS idx_string_entries_character_frequency_map
+    ON string_entries USING gin (character_frequency_map);
+CREATE INDEX IF NOT EXISTS idx_string_entries_tags
+    ON string_entries USING gin (tags);
+
+-- Values can be far larger than a B-tree entry allows, so duplicates are
+-- detected through their hash
+CREATE UNIQUE INDEX IF NOT EXISTS idx_string_entries_sha256_hash
+    ON string_entries (collection, sha256_hash);
@@ -0,0 +1,14 @@
+-- Responses to requests sent with an Idempotency-Key header, replayed when
+-- the client retries. status_code is 0 while the first request is running.
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    client_key  text        NOT NULL,
+    key         text        NOT NULL,
+    fingerprint text        NOT NULL,
+    status_code integer     NOT NULL DEFAULT 0,
+    headers     jsonb       NOT NULL DEFAULT '{}',
+    body        bytea,
+    created_at  timestamptz NOT NULL,
+    PRIMARY KEY (client_key, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_created_at ON idempotency_keys (created_at);
@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS string_entries_deleted ON string_entries;
+DROP TRIGGER IF EXISTS string_entries_updated ON string_entries;
+DROP TRIGGER IF EXISTS string_entries_inserted ON string_entries;
+DROP FUNCTION IF EXISTS bump_collection_versions();
+ALTER TABLE collections DROP COLUMN IF EXISTS version;
+DROP SEQUENCE IF EXISTS collection_versions;
@@ -0,0 +1,34 @@
+-- Every write to a collection's strings moves its version on, which gives
+-- list responses a cheap validator. Versions come from one sequence so a
+-- collection recreated under an old name never reuses a version.
+CREATE SEQUENCE IF NOT EXISTS collection_versions;
+
+ALTER TABLE collections
+    ADD COLUMN IF NOT EXISTS version bigint NOT NULL DEFAULT nextval('collection_versions');
+
+-- Statement level, so a purge of many rows bumps each collection once
+CREATE OR REPLACE FUNCTION bump_collection_versions() RETURNS trigger AS $$
+BEGIN
+    UPDATE collections SET version = nextval('collection_versions')
+    WHERE name IN (SELECT DISTINCT collection FROM changed_rows);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS string_entries_inserted ON string_entries;
+CREATE TRIGGER string_entries_inserted
+    AFTER INSERT ON string_entries
+    REFERENCING NEW TABLE AS changed_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION bump_collection_versions();
+
+DROP TRIGGER IF EXISTS string_entries_updated ON string_entries;
+CREATE TRIGGER string_entries_updated
+    AFTER UPDATE ON string_entries
+    REFERENCING NEW TABLE AS changed_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION bump_collection_versions();
+
+DROP TRIGGER IF EXISTS string_entries_deleted ON string_entries;
+CREATE TRIGGER string_entries_deleted
+    AFTER DELETE ON string_entries
+    REFERENCING OLD TABLE AS changed_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION bump_collection_versions();
@@ -0,0 +1,14 @@
+DROP INDEX IF EXISTS idx_string_entries_missing_statistics;
+
+ALTER TABLE string_entries
+    DROP COLUMN IF EXISTS entropy,
+    DROP COLUMN IF EXISTS letter_count,
+    DROP COLUMN IF EXISTS digit_count,
+    DROP COLUMN IF EXISTS whitespace_count,
+    DROP COLUMN IF EXISTS punctuation_count,
+    DROP COLUMN IF EXISTS symbol_count,
+    DROP COLUMN IF EXISTS uppercase_count,
+    DROP COLUMN IF EXISTS lowercase_count,
+    DROP COLUMN IF EXISTS vowel_count,
+    DROP COLUMN IF EXISTS consonant_count,
+    DROP COLUMN IF EXISTS longest_run;
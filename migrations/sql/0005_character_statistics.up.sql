@@ -0,0 +1,19 @@
+-- Character statistics. Rows stored before this migration hold NULL until
+-- the backfill command computes them; the analysis has to run in Go to
+-- match new entries exactly.
+ALTER TABLE string_entries
+    ADD COLUMN IF NOT EXISTS entropy           double precision,
+    ADD COLUMN IF NOT EXISTS letter_count      bigint,
+    ADD COLUMN IF NOT EXISTS digit_count       bigint,
+    ADD COLUMN IF NOT EXISTS whitespace_count  bigint,
+    ADD COLUMN IF NOT EXISTS punctuation_count bigint,
+    ADD COLUMN IF NOT EXISTS symbol_count      bigint,
+    ADD COLUMN IF NOT EXISTS uppercase_count   bigint,
+    ADD COLUMN IF NOT EXISTS lowercase_count   bigint,
+    ADD COLUMN IF NOT EXISTS vowel_count       bigint,
+    ADD COLUMN IF NOT EXISTS consonant_count   bigint,
+    ADD COLUMN IF NOT EXISTS longest_run       bigint;
+
+-- Lets the backfill find its remaining work without a full scan
+CREATE INDEX IF NOT EXISTS idx_string_entries_missing_statistics
+    ON string_entries (collection, id) WHERE entropy IS NULL;
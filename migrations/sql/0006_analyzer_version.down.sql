@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_string_entries_analyzer_version;
+
+CREATE INDEX IF NOT EXISTS idx_string_entries_missing_statistics
+    ON string_entries (collection, id) WHERE entropy IS NULL;
+
+ALTER TABLE string_entries DROP COLUMN IF EXISTS analyzer_version;
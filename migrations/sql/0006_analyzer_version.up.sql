@@ -0,0 +1,15 @@
+-- The analysis version that computed each row. Rows without character
+-- statistics were computed by version 1, the rest by version 2.
+ALTER TABLE string_entries ADD COLUMN IF NOT EXISTS analyzer_version integer;
+
+UPDATE string_entries
+SET analyzer_version = CASE WHEN entropy IS NULL THEN 1 ELSE 2 END
+WHERE analyzer_version IS NULL;
+
+ALTER TABLE string_entries ALTER COLUMN analyzer_version SET NOT NULL;
+
+-- Recomputing replaces the statistics backfill, and finds its work by version
+DROP INDEX IF EXISTS idx_string_entries_missing_statistics;
+
+CREATE INDEX IF NOT EXISTS idx_string_entries_analyzer_version
+    ON string_entries (analyzer_version, collection, id);
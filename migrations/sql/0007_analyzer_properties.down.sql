@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_string_entries_properties;
+
+ALTER TABLE string_entries
+    DROP COLUMN IF EXISTS properties,
+    DROP COLUMN IF EXISTS property_versions;
@@ -0,0 +1,11 @@
+-- Results of the registered analyzers keyed by analyzer name, and the
+-- version of the analyzer behind each. Existing rows start empty and are
+-- filled in by a recompute.
+ALTER TABLE string_entries
+    ADD COLUMN IF NOT EXISTS properties        jsonb NOT NULL DEFAULT '{}',
+    ADD COLUMN IF NOT EXISTS property_versions jsonb NOT NULL DEFAULT '{}';
+
+-- Equality filters on properties use containment (@>) and key filters a
+-- jsonpath exists() test (@@), like the other JSONB filters
+CREATE INDEX IF NOT EXISTS idx_string_entries_properties
+    ON string_entries USING gin (properties);
@@ -0,0 +1 @@
+ALTER TABLE string_entries DROP CONSTRAINT IF EXISTS string_entries_collection_fkey;
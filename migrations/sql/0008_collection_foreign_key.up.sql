@@ -0,0 +1,21 @@
+-- Strings must belong to an existing collection. RESTRICT makes deleting a
+-- collection fail while it holds strings, trashed ones included, however the
+-- delete races with the writes that add them.
+
+-- Adopt collections that were deleted under strings before this existed
+INSERT INTO collections (name, created_at)
+SELECT DISTINCT collection, now() FROM string_entries
+ON CONFLICT DO NOTHING;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conname = 'string_entries_collection_fkey'
+            AND conrelid = 'string_entries'::regclass) THEN
+        ALTER TABLE string_entries
+            ADD CONSTRAINT string_entries_collection_fkey
+            FOREIGN KEY (collection) REFERENCES collections (name) ON DELETE RESTRICT;
+    END IF;
+END
+$$;
@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_string_entries_sha256_hash
+    ON string_entries (collection, sha256_hash);
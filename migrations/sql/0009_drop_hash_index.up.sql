@@ -0,0 +1,4 @@
+-- An entry's id is the SHA-256 hash of its value, so the primary key on
+-- (collection, id) already keeps values unique and serves lookups by hash.
+-- The unique index on (collection, sha256_hash) only cost every write.
+DROP INDEX IF EXISTS idx_string_entries_sha256_hash;
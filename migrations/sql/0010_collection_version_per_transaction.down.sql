@@ -0,0 +1,26 @@
+DROP TRIGGER IF EXISTS string_entries_bump_collection_version ON string_entries;
+DROP FUNCTION IF EXISTS bump_collection_version();
+
+-- The statement level triggers of 0004
+CREATE OR REPLACE FUNCTION bump_collection_versions() RETURNS trigger AS $$
+BEGIN
+    UPDATE collections SET version = nextval('collection_versions')
+    WHERE name IN (SELECT DISTINCT collection FROM changed_rows);
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER string_entries_inserted
+    AFTER INSERT ON string_entries
+    REFERENCING NEW TABLE AS changed_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION bump_collection_versions();
+
+CREATE TRIGGER string_entries_updated
+    AFTER UPDATE ON string_entries
+    REFERENCING NEW TABLE AS changed_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION bump_collection_versions();
+
+CREATE TRIGGER string_entries_deleted
+    AFTER DELETE ON string_entries
+    REFERENCING OLD TABLE AS changed_rows
+    FOR EACH STATEMENT EXECUTE FUNCTION bump_collection_versions();
@@ -0,0 +1,48 @@
+-- Bump a collection's version once per transaction rather than on every
+-- statement, so a transaction writing many strings updates the collection
+-- row once.
+--
+-- The trigger runs before the row is written, so a create locks the
+-- collection row before its foreign key check does. A create racing
+-- DeleteCollection therefore waits on the same lock as the delete, instead
+-- of each holding a lock the other needs. An insert that turns out to be a
+-- duplicate still bumps the version, which only costs clients a revalidation.
+DROP TRIGGER IF EXISTS string_entries_inserted ON string_entries;
+DROP TRIGGER IF EXISTS string_entries_updated ON string_entries;
+DROP TRIGGER IF EXISTS string_entries_deleted ON string_entries;
+DROP FUNCTION IF EXISTS bump_collection_versions();
+
+-- string_analyzer.bumped_collections lists, between commas, the collections
+-- the current transaction has already bumped. set_config(..., true) scopes
+-- it to the transaction.
+CREATE OR REPLACE FUNCTION bump_collection_version() RETURNS trigger AS $$
+DECLARE
+    changed text;
+    bumped  text;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := OLD.collection;
+    ELSE
+        changed := NEW.collection;
+    END IF;
+
+    bumped := coalesce(current_setting('string_analyzer.bumped_collections', true), '');
+    IF position(',' || changed || ',' IN bumped) = 0 THEN
+        UPDATE collections SET version = nextval('collection_versions') WHERE name = changed;
+        IF bumped = '' THEN
+            bumped := ',';
+        END IF;
+        PERFORM set_config('string_analyzer.bumped_collections', bumped || changed || ',', true);
+    END IF;
+
+    IF TG_OP = 'DELETE' THEN
+        RETURN OLD;
+    END IF;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS string_entries_bump_collection_version ON string_entries;
+CREATE TRIGGER string_entries_bump_collection_version
+    BEFORE INSERT OR UPDATE OR DELETE ON string_entries
+    FOR EACH ROW EXECUTE FUNCTION bump_collection_version();
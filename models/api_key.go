@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// APIKey is a client credential. Only the SHA-256 hash of the key is stored;
+// the plaintext is shown once when the key is minted.
+type APIKey struct {
+	ID         string         `gorm:"primaryKey;type:text" json:"id"`
+	Name       string         `gorm:"type:text;not null" json:"name"`
+	Prefix     string         `gorm:"type:text;not null" json:"prefix"`
+	KeyHash    string         `gorm:"type:text;not null;uniqueIndex" json:"-"`
+	Scopes     datatypes.JSON `gorm:"type:jsonb;not null" json:"scopes"`
+	CreatedAt  time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	LastUsedAt *time.Time     `json:"last_used_at"`
+	RevokedAt  *time.Time     `json:"revoked_at"`
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// ClientQuota counts the strings a client created on a given UTC day
+type ClientQuota struct {
+	ClientKey string    `gorm:"primaryKey;type:text" json:"client_key"`
+	Day       time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Count     int64     `gorm:"not null;default:0" json:"count"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Collection is a namespace for string entries. A value only has to be unique
+// within its collection.
+type Collection struct {
+	Name      string    `gorm:"primaryKey;type:text" json:"name"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	// Version changes whenever a string in the collection is written. It is
+	// maintained by the database.
+	Version int64 `gorm:"->" json:"version"`
+}
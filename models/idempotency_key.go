@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// IdempotencyKey remembers the response to a request sent with an
+// Idempotency-Key header so a retry receives the same answer. StatusCode is
+// 0 while the first request is still being handled.
+type IdempotencyKey struct {
+	ClientKey   string         `gorm:"primaryKey;type:text" json:"client_key"`
+	Key         string         `gorm:"primaryKey;type:text" json:"key"`
+	Fingerprint string         `gorm:"type:text;not null" json:"fingerprint"`
+	StatusCode  int            `gorm:"not null;default:0" json:"status_code"`
+	Headers     datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"headers"`
+	Body        []byte         `gorm:"type:bytea" json:"body"`
+	CreatedAt   time.Time      `gorm:"not null" json:"created_at"`
+}
@@ -1,12 +1,15 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/datatypes"
+	"gorm.io/gorm"
 )
 
 type StringEntry struct {
+	Collection            string         `gorm:"primaryKey;type:text;default:'default'" json:"collection"`
 	ID                    string         `gorm:"primaryKey;type:text" json:"id"`
 	Value                 string         `gorm:"type:text;not null" json:"value"`
 	Length                int            `gorm:"not null" json:"length"`
@@ -15,14 +18,133 @@ type StringEntry struct {
 	WordCount             int            `gorm:"not null" json:"word_count"`
 	SHA256Hash            string         `gorm:"type:text;not null" json:"sha256_hash"`
 	CharacterFrequencyMap datatypes.JSON `gorm:"type:jsonb;not null" json:"character_frequency_map"`
+	Tags                  datatypes.JSON `gorm:"type:jsonb;not null;default:'[]'" json:"tags"`
+	Metadata              datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"metadata"`
+	CreatedBy             string         `gorm:"type:text;not null;default:''" json:"created_by"`
 	CreatedAt             time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	DeletedAt             gorm.DeletedAt `gorm:"index" json:"deleted_at"`
+
+	// AnalyzerVersion is the version of the analysis that computed the
+	// properties of the entry
+	AnalyzerVersion int `gorm:"not null" json:"analyzer_version"`
+	// Properties maps the name of each registered analyzer to its result, and
+	// PropertyVersions to the analyzer version that computed it
+	Properties       datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"properties"`
+	PropertyVersions datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"property_versions"`
+
+	// Character statistics are nil on rows stored before they existed,
+	// until they are recomputed
+	Entropy          *float64 `json:"entropy"`
+	LetterCount      *int     `json:"letter_count"`
+	DigitCount       *int     `json:"digit_count"`
+	WhitespaceCount  *int     `json:"whitespace_count"`
+	PunctuationCount *int     `json:"punctuation_count"`
+	SymbolCount      *int     `json:"symbol_count"`
+	UppercaseCount   *int     `json:"uppercase_count"`
+	LowercaseCount   *int     `json:"lowercase_count"`
+	VowelCount       *int     `json:"vowel_count"`
+	ConsonantCount   *int     `json:"consonant_count"`
+	LongestRun       *int     `json:"longest_run"`
 }
 
+// CurrentAnalyzerVersion identifies the built-in analysis this build
+// performs. Bump it whenever a change to the analysis would give an existing
+// value different built-in properties, so the stored rows can be found and
+// recomputed. Registered analyzers carry versions of their own.
+//
+//  1. length, is_palindrome, unique_characters, word_count, sha256_hash and
+//     character_frequency_map
+//  2. character statistics
+const CurrentAnalyzerVersion = 2
+
 type StringDetails struct {
-	Hash         string         `json:"sha256_hash"`
-	Length       int            `json:"length"`
-	IsPalindrome bool           `json:"is_palindrome"`
-	UniqueChars  int            `json:"unique_characters"`
-	WordCount    int            `json:"word_count"`
-	FreqMap      map[string]int `json:"character_frequency_map"`
+	Hash         string              `json:"sha256_hash"`
+	Length       int                 `json:"length"`
+	IsPalindrome bool                `json:"is_palindrome"`
+	UniqueChars  int                 `json:"unique_characters"`
+	WordCount    int                 `json:"word_count"`
+	FreqMap      map[string]int      `json:"character_frequency_map"`
+	Statistics   CharacterStatistics `json:"statistics"`
+	// Properties and PropertyVersions hold the results of the registered
+	// analyzers and their versions, keyed by analyzer name
+	Properties       map[string]any `json:"properties"`
+	PropertyVersions map[string]int `json:"property_versions"`
+}
+
+// CharacterStatistics describes the characters of a value. Counts are of
+// Unicode code points; Entropy is the Shannon entropy in bits per code point.
+type CharacterStatistics struct {
+	Entropy     float64 `json:"entropy"`
+	Letters     int     `json:"letter_count"`
+	Digits      int     `json:"digit_count"`
+	Whitespace  int     `json:"whitespace_count"`
+	Punctuation int     `json:"punctuation_count"`
+	Symbols     int     `json:"symbol_count"`
+	Uppercase   int     `json:"uppercase_count"`
+	Lowercase   int     `json:"lowercase_count"`
+	Vowels      int     `json:"vowel_count"`
+	Consonants  int     `json:"consonant_count"`
+	LongestRun  int     `json:"longest_run"`
+}
+
+// StatisticColumns are the character statistics filterable by range. Each
+// name is both the property name in responses and the column name.
+var StatisticColumns = []string{
+	"entropy",
+	"letter_count",
+	"digit_count",
+	"whitespace_count",
+	"punctuation_count",
+	"symbol_count",
+	"uppercase_count",
+	"lowercase_count",
+	"vowel_count",
+	"consonant_count",
+	"longest_run",
+}
+
+// AnalysisColumns are the columns written by the analysis, which a recompute
+// replaces
+var AnalysisColumns = append([]string{
+	"length",
+	"is_palindrome",
+	"unique_characters",
+	"word_count",
+	"sha256_hash",
+	"character_frequency_map",
+	"analyzer_version",
+	"properties",
+	"property_versions",
+}, StatisticColumns...)
+
+// SetDetails stores the analysis of the entry's value on it
+func (e *StringEntry) SetDetails(details StringDetails) {
+	freqMapJSON, _ := json.Marshal(details.FreqMap)
+	propertiesJSON, _ := json.Marshal(details.Properties)
+	versionsJSON, _ := json.Marshal(details.PropertyVersions)
+	e.Length = details.Length
+	e.IsPalindrome = details.IsPalindrome
+	e.UniqueCharacters = details.UniqueChars
+	e.WordCount = details.WordCount
+	e.SHA256Hash = details.Hash
+	e.CharacterFrequencyMap = freqMapJSON
+	e.AnalyzerVersion = CurrentAnalyzerVersion
+	e.Properties = propertiesJSON
+	e.PropertyVersions = versionsJSON
+	e.SetStatistics(details.Statistics)
+}
+
+// SetStatistics stores statistics on the entry
+func (e *StringEntry) SetStatistics(statistics CharacterStatistics) {
+	e.Entropy = &statistics.Entropy
+	e.LetterCount = &statistics.Letters
+	e.DigitCount = &statistics.Digits
+	e.WhitespaceCount = &statistics.Whitespace
+	e.PunctuationCount = &statistics.Punctuation
+	e.SymbolCount = &statistics.Symbols
+	e.UppercaseCount = &statistics.Uppercase
+	e.LowercaseCount = &statistics.Lowercase
+	e.VowelCount = &statistics.Vowels
+	e.ConsonantCount = &statistics.Consonants
+	e.LongestRun = &statistics.LongestRun
 }
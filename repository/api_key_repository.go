@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"task_one/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey) (*models.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error)
+	ListAPIKeys(ctx context.Context) (*[]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) (bool, error)
+	TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB, logger *slog.Logger) APIKeyRepository {
+	return &apiKeyRepository{db: withLogger(db, logger, "api_keys")}
+}
+
+func (r apiKeyRepository) CreateAPIKey(ctx context.Context, key models.APIKey) (*models.APIKey, error) {
+	if err := r.db.WithContext(ctx).Create(&key).Error; err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &key, nil
+}
+
+func (r apiKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &key, nil
+}
+
+func (r apiKeyRepository) ListAPIKeys(ctx context.Context) (*[]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := r.db.WithContext(ctx).Order("created_at").Find(&keys).Error; err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &keys, nil
+}
+
+func (r apiKeyRepository) RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt)
+	if result.Error != nil {
+		return false, contextError(ctx, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r apiKeyRepository) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ?", id).
+		Update("last_used_at", usedAt).Error
+	return contextError(ctx, err)
+}
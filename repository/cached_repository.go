@@ -0,0 +1,295 @@
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"task_one/cache"
+	"task_one/dto"
+	"task_one/metrics"
+	"task_one/models"
+	"time"
+)
+
+// Cached calls, as reported in metrics
+const (
+	entryCache  = "entry"
+	filterCache = "filter"
+	statsCache  = "stats"
+)
+
+// cachedStringRepository serves lookups and filter results from a cache in
+// front of the wrapped repository.
+//
+// Entries, filter results and stats are keyed by a generation token per
+// collection along with the id or normalized filter. Every write replaces the
+// token, which orphans the collection's cached values until they expire.
+// Because the token is read before the database, a value loaded while a
+// write lands is stored under the token the write replaced and never served.
+// A cache failure is logged and the call falls through to the wrapped
+// repository.
+type cachedStringRepository struct {
+	next    StringRepository
+	cache   cache.Cache
+	ttl     time.Duration
+	metrics *metrics.Metrics
+	logger  *slog.Logger
+}
+
+func NewCachedStringRepository(next StringRepository, c cache.Cache, ttl time.Duration, m *metrics.Metrics, logger *slog.Logger) StringRepository {
+	return &cachedStringRepository{
+		next:    next,
+		cache:   c,
+		ttl:     ttl,
+		metrics: m,
+		logger:  logger.With("repository", "strings_cache"),
+	}
+}
+
+// GetStringByHash shares the entry cache with GetStringById because an
+// entry's id is its hash
+func (r cachedStringRepository) GetStringByHash(ctx context.Context, collection, hash string) (*models.StringEntry, error) {
+	return r.cachedEntry(ctx, collection, hash, r.next.GetStringByHash)
+}
+
+func (r cachedStringRepository) GetStringById(ctx context.Context, collection, id string) (*models.StringEntry, error) {
+	return r.cachedEntry(ctx, collection, id, r.next.GetStringById)
+}
+
+func (r cachedStringRepository) cachedEntry(ctx context.Context, collection, id string, load func(context.Context, string, string) (*models.StringEntry, error)) (*models.StringEntry, error) {
+	generation, err := r.generation(ctx, collection)
+	if err != nil {
+		r.metrics.ObserveCacheLookup(entryCache, metrics.CacheError)
+		r.logger.WarnContext(ctx, "Failed to read cache generation", slog.String("collection", collection), slog.Any("error", err))
+		return load(ctx, collection, id)
+	}
+
+	key := entryKey(collection, generation, id)
+	var entry models.StringEntry
+	if r.lookup(ctx, entryCache, key, &entry) {
+		return &entry, nil
+	}
+
+	found, err := load(ctx, collection, id)
+	if err != nil || found == nil {
+		return found, err
+	}
+	r.store(ctx, key, found)
+	return found, nil
+}
+
+func (r cachedStringRepository) FilterByCriteria(ctx context.Context, collection string, input dto.FilterByCriteriaData) (*[]models.StringEntry, error) {
+	generation, err := r.generation(ctx, collection)
+	if err != nil {
+		r.metrics.ObserveCacheLookup(filterCache, metrics.CacheError)
+		r.logger.WarnContext(ctx, "Failed to read cache generation", slog.String("collection", collection), slog.Any("error", err))
+		return r.next.FilterByCriteria(ctx, collection, input)
+	}
+
+	key := filterKey(collection, generation, input)
+	var entries []models.StringEntry
+	if r.lookup(ctx, filterCache, key, &entries) {
+		return &entries, nil
+	}
+
+	found, err := r.next.FilterByCriteria(ctx, collection, input)
+	if err != nil {
+		return nil, err
+	}
+	r.store(ctx, key, found)
+	return found, nil
+}
+
+// Stats shares the generation tokens of the filter results, so any write to
+// the collection recomputes them
+func (r cachedStringRepository) Stats(ctx context.Context, collection string, ngramSizes []int, top int) (*StringStats, error) {
+	generation, err := r.generation(ctx, collection)
+	if err != nil {
+		r.metrics.ObserveCacheLookup(statsCache, metrics.CacheError)
+		r.logger.WarnContext(ctx, "Failed to read cache generation", slog.String("collection", collection), slog.Any("error", err))
+		return r.next.Stats(ctx, collection, ngramSizes, top)
+	}
+
+	key := statsKey(collection, generation, ngramSizes, top)
+	var stats StringStats
+	if r.lookup(ctx, statsCache, key, &stats) {
+		return &stats, nil
+	}
+
+	found, err := r.next.Stats(ctx, collection, ngramSizes, top)
+	if err != nil {
+		return nil, err
+	}
+	r.store(ctx, key, found)
+	return found, nil
+}
+
+func (r cachedStringRepository) CreateNewStringRecord(ctx context.Context, stringData models.StringEntry) (bool, error) {
+	created, err := r.next.CreateNewStringRecord(ctx, stringData)
+	if created {
+		r.invalidate(ctx, stringData.Collection)
+	}
+	return created, err
+}
+
+// Conditional writes check their precondition against the database, never
+// the cache, since the wrapped repository locks and reads the row itself
+
+func (r cachedStringRepository) UpdateStringRecord(ctx context.Context, collection, hash string, updates map[string]any, precondition Precondition) (bool, error) {
+	updated, err := r.next.UpdateStringRecord(ctx, collection, hash, updates, precondition)
+	if updated {
+		r.invalidate(ctx, collection)
+	}
+	return updated, err
+}
+
+func (r cachedStringRepository) DeleteStringValue(ctx context.Context, collection, hash string, precondition Precondition) (bool, error) {
+	deleted, err := r.next.DeleteStringValue(ctx, collection, hash, precondition)
+	if deleted {
+		r.invalidate(ctx, collection)
+	}
+	return deleted, err
+}
+
+func (r cachedStringRepository) RestoreStringValue(ctx context.Context, collection, hash string) (bool, error) {
+	restored, err := r.next.RestoreStringValue(ctx, collection, hash)
+	if restored {
+		r.invalidate(ctx, collection)
+	}
+	return restored, err
+}
+
+// SaveAnalysis replaces the token of each collection once, rather than once
+// per saved entry
+func (r cachedStringRepository) SaveAnalysis(ctx context.Context, entries []models.StringEntry) (int64, error) {
+	saved, err := r.next.SaveAnalysis(ctx, entries)
+	if err != nil || saved == 0 {
+		return saved, err
+	}
+
+	var collections []string
+	for _, entry := range entries {
+		if !slices.Contains(collections, entry.Collection) {
+			collections = append(collections, entry.Collection)
+			r.invalidate(ctx, entry.Collection)
+		}
+	}
+	return saved, nil
+}
+
+// Trashed entries are never cached and the recompute scan must see the
+// database, so the calls below pass straight through
+
+func (r cachedStringRepository) ExplainFilter(ctx context.Context, collection string, input dto.FilterByCriteriaData, analyze bool) (*FilterPlan, error) {
+	return r.next.ExplainFilter(ctx, collection, input, analyze)
+}
+
+func (r cachedStringRepository) ListDeleted(ctx context.Context, collection string) (*[]models.StringEntry, error) {
+	return r.next.ListDeleted(ctx, collection)
+}
+
+func (r cachedStringRepository) CountStale(ctx context.Context) (int64, error) {
+	return r.next.CountStale(ctx)
+}
+
+func (r cachedStringRepository) ListStale(ctx context.Context, after StaleCursor, limit int) (*[]models.StringEntry, error) {
+	return r.next.ListStale(ctx, after, limit)
+}
+
+func (r cachedStringRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return r.next.PurgeDeletedBefore(ctx, cutoff)
+}
+
+// lookup decodes the cached value at key into target and reports a hit
+func (r cachedStringRepository) lookup(ctx context.Context, name, key string, target any) bool {
+	value, ok, err := r.cache.Get(ctx, key)
+	if err != nil {
+		r.metrics.ObserveCacheLookup(name, metrics.CacheError)
+		r.logger.WarnContext(ctx, "Failed to read from cache", slog.String("cache", name), slog.Any("error", err))
+		return false
+	}
+	if !ok {
+		r.metrics.ObserveCacheLookup(name, metrics.CacheMiss)
+		return false
+	}
+	if err := json.Unmarshal(value, target); err != nil {
+		r.metrics.ObserveCacheLookup(name, metrics.CacheError)
+		r.logger.WarnContext(ctx, "Discarding unreadable cache value", slog.String("cache", name), slog.Any("error", err))
+		return false
+	}
+	r.metrics.ObserveCacheLookup(name, metrics.CacheHit)
+	return true
+}
+
+func (r cachedStringRepository) store(ctx context.Context, key string, value any) {
+	encoded, err := json.Marshal(value)
+	if err == nil {
+		err = r.cache.Set(ctx, key, encoded, r.ttl)
+	}
+	if err != nil {
+		r.logger.WarnContext(ctx, "Failed to write to cache", slog.Any("error", err))
+	}
+}
+
+// generation returns the collection's current token, starting a new one when
+// there is none
+func (r cachedStringRepository) generation(ctx context.Context, collection string) (string, error) {
+	value, ok, err := r.cache.Get(ctx, generationKey(collection))
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(value), nil
+	}
+	return r.nextGeneration(ctx, collection)
+}
+
+func (r cachedStringRepository) nextGeneration(ctx context.Context, collection string) (string, error) {
+	token := make([]byte, 8)
+	_, _ = rand.Read(token)
+	generation := hex.EncodeToString(token)
+	// The token outlives the results it keys, so it is kept without a TTL
+	if err := r.cache.Set(ctx, generationKey(collection), []byte(generation), 0); err != nil {
+		return "", err
+	}
+	return generation, nil
+}
+
+// invalidate orphans the collection's cached entries, filter results and
+// stats. The write has already happened, so this must land even when the
+// client has gone away.
+func (r cachedStringRepository) invalidate(ctx context.Context, collection string) {
+	ctx = context.WithoutCancel(ctx)
+	if _, err := r.nextGeneration(ctx, collection); err != nil {
+		r.logger.ErrorContext(ctx, "Failed to invalidate cache, stale results are served until they expire",
+			slog.String("collection", collection), slog.Any("error", err))
+	}
+}
+
+// Collection names cannot contain ':', which keeps the keys unambiguous
+
+func entryKey(collection, generation, id string) string {
+	return "entry:" + collection + ":" + generation + ":" + id
+}
+
+func generationKey(collection string) string {
+	return "generation:" + collection
+}
+
+// filterKey hashes the filter so equivalent queries share a key: JSON
+// encoding sorts the metadata keys and tags are sorted here
+func filterKey(collection, generation string, input dto.FilterByCriteriaData) string {
+	input.Tags = slices.Compact(slices.Sorted(slices.Values(input.Tags)))
+	encoded, _ := json.Marshal(input)
+	sum := sha256.Sum256(encoded)
+	return "filter:" + collection + ":" + generation + ":" + hex.EncodeToString(sum[:])
+}
+
+func statsKey(collection, generation string, ngramSizes []int, top int) string {
+	return fmt.Sprintf("stats:%s:%s:%v:%d", collection, generation, ngramSizes, top)
+}
@@ -0,0 +1,335 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"task_one/cache"
+	"task_one/dto"
+	"task_one/metrics"
+	"task_one/models"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// fakeStringRepository keeps entries in memory and counts the calls that
+// reach it, so tests can tell cache hits from database reads
+type fakeStringRepository struct {
+	StringRepository
+
+	mu      sync.Mutex
+	entries map[string]models.StringEntry
+	deleted map[string]models.StringEntry
+	reads   int
+	// beforeReturn runs once a lookup has read its row, standing in for a
+	// write that lands while the lookup is still on its way back
+	beforeReturn func()
+}
+
+func newFakeStringRepository() *fakeStringRepository {
+	return &fakeStringRepository{
+		entries: map[string]models.StringEntry{},
+		deleted: map[string]models.StringEntry{},
+	}
+}
+
+func (r *fakeStringRepository) CreateNewStringRecord(ctx context.Context, stringData models.StringEntry) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := stringData.Collection + "/" + stringData.ID
+	if _, ok := r.entries[key]; ok {
+		return false, nil
+	}
+	r.entries[key] = stringData
+	return true, nil
+}
+
+func (r *fakeStringRepository) GetStringById(ctx context.Context, collection, id string) (*models.StringEntry, error) {
+	r.mu.Lock()
+	r.reads++
+	entry, ok := r.entries[collection+"/"+id]
+	beforeReturn := r.beforeReturn
+	r.beforeReturn = nil
+	r.mu.Unlock()
+
+	if beforeReturn != nil {
+		beforeReturn()
+	}
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (r *fakeStringRepository) GetStringByHash(ctx context.Context, collection, hash string) (*models.StringEntry, error) {
+	return r.GetStringById(ctx, collection, hash)
+}
+
+func (r *fakeStringRepository) FilterByCriteria(ctx context.Context, collection string, input dto.FilterByCriteriaData) (*[]models.StringEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reads++
+	var found []models.StringEntry
+	for _, entry := range r.entries {
+		if entry.Collection == collection {
+			found = append(found, entry)
+		}
+	}
+	return &found, nil
+}
+
+func (r *fakeStringRepository) UpdateStringRecord(ctx context.Context, collection, hash string, updates map[string]any, precondition Precondition) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[collection+"/"+hash]
+	if !ok {
+		return false, nil
+	}
+	if createdBy, ok := updates["created_by"].(string); ok {
+		entry.CreatedBy = createdBy
+	}
+	r.entries[collection+"/"+hash] = entry
+	return true, nil
+}
+
+func (r *fakeStringRepository) DeleteStringValue(ctx context.Context, collection, hash string, precondition Precondition) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[collection+"/"+hash]
+	if !ok {
+		return false, nil
+	}
+	r.deleted[collection+"/"+hash] = entry
+	delete(r.entries, collection+"/"+hash)
+	return true, nil
+}
+
+func (r *fakeStringRepository) RestoreStringValue(ctx context.Context, collection, hash string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.deleted[collection+"/"+hash]
+	if !ok {
+		return false, nil
+	}
+	delete(r.deleted, collection+"/"+hash)
+	r.entries[collection+"/"+hash] = entry
+	return true, nil
+}
+
+func (r *fakeStringRepository) readCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reads
+}
+
+type cacheFixture struct {
+	repo     StringRepository
+	next     *fakeStringRepository
+	registry *prometheus.Registry
+}
+
+// newCacheFixture puts the cache decorator, backed by an in-memory Redis
+// server, in front of a fake repository
+func newCacheFixture(t *testing.T) cacheFixture {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	registry := prometheus.NewRegistry()
+	next := newFakeStringRepository()
+	repo := NewCachedStringRepository(next, cache.NewRedis(client, "test:"), time.Minute, metrics.New(registry), slog.New(slog.DiscardHandler))
+	return cacheFixture{repo: repo, next: next, registry: registry}
+}
+
+// lookups returns the cache lookups recorded for cache with result
+func (f cacheFixture) lookups(t *testing.T, cache, result string) float64 {
+	t.Helper()
+	families, err := f.registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "string_analyzer_cache_lookups_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["cache"] == cache && labels["result"] == result {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+// get looks id up and reports whether the lookup reached the database
+func (f cacheFixture) get(t *testing.T, id string) (*models.StringEntry, bool) {
+	t.Helper()
+	reads := f.next.readCount()
+	entry, err := f.repo.GetStringById(context.Background(), "default", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return entry, f.next.readCount() > reads
+}
+
+func TestCachedEntryHitAndMiss(t *testing.T) {
+	f := newCacheFixture(t)
+	ctx := context.Background()
+	if _, err := f.repo.CreateNewStringRecord(ctx, models.StringEntry{Collection: "default", ID: "a", Value: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, fromDB := f.get(t, "a"); !fromDB {
+		t.Fatal("first lookup did not reach the database")
+	}
+	entry, fromDB := f.get(t, "a")
+	if fromDB {
+		t.Fatal("second lookup was not served from the cache")
+	}
+	if entry == nil || entry.Value != "a" {
+		t.Fatalf("cached entry %+v, want value a", entry)
+	}
+	if _, err := f.repo.GetStringByHash(ctx, "default", "a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if hits, misses := f.lookups(t, entryCache, metrics.CacheHit), f.lookups(t, entryCache, metrics.CacheMiss); hits != 2 || misses != 1 {
+		t.Fatalf("entry lookups: %v hits and %v misses, want 2 and 1", hits, misses)
+	}
+
+	// Missing entries are not cached
+	f.get(t, "missing")
+	if _, fromDB := f.get(t, "missing"); !fromDB {
+		t.Fatal("missing entry was served from the cache")
+	}
+}
+
+func TestCachedFilterHitAndMiss(t *testing.T) {
+	f := newCacheFixture(t)
+	ctx := context.Background()
+	input := dto.FilterByCriteriaData{Tags: []string{"b", "a"}}
+
+	for range 2 {
+		if _, err := f.repo.FilterByCriteria(ctx, "default", input); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The same tags in another order share the cached result
+	if _, err := f.repo.FilterByCriteria(ctx, "default", dto.FilterByCriteriaData{Tags: []string{"a", "b"}}); err != nil {
+		t.Fatal(err)
+	}
+	if reads := f.next.readCount(); reads != 1 {
+		t.Fatalf("filter reached the database %d times, want 1", reads)
+	}
+	if hits, misses := f.lookups(t, filterCache, metrics.CacheHit), f.lookups(t, filterCache, metrics.CacheMiss); hits != 2 || misses != 1 {
+		t.Fatalf("filter lookups: %v hits and %v misses, want 2 and 1", hits, misses)
+	}
+}
+
+func TestCacheInvalidatedByWrites(t *testing.T) {
+	ctx := context.Background()
+	writes := []struct {
+		name  string
+		write func(StringRepository) error
+	}{
+		{"create", func(repo StringRepository) error {
+			_, err := repo.CreateNewStringRecord(ctx, models.StringEntry{Collection: "default", ID: "b", Value: "b"})
+			return err
+		}},
+		{"patch", func(repo StringRepository) error {
+			_, err := repo.UpdateStringRecord(ctx, "default", "a", map[string]any{"created_by": "someone"}, nil)
+			return err
+		}},
+		{"delete", func(repo StringRepository) error {
+			_, err := repo.DeleteStringValue(ctx, "default", "a", nil)
+			return err
+		}},
+		{"restore", func(repo StringRepository) error {
+			_, err := repo.RestoreStringValue(ctx, "default", "a")
+			return err
+		}},
+	}
+
+	for _, tc := range writes {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newCacheFixture(t)
+			// A trashed entry is never cached, so the live c shows that a
+			// restore drops the collection's cached lookups too
+			ids := []string{"a", "c"}
+			for _, id := range ids {
+				if _, err := f.repo.CreateNewStringRecord(ctx, models.StringEntry{Collection: "default", ID: id, Value: id}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.name == "restore" {
+				if _, err := f.repo.DeleteStringValue(ctx, "default", "a", nil); err != nil {
+					t.Fatal(err)
+				}
+				ids = []string{"c"}
+			}
+
+			// Warm both caches
+			for _, id := range ids {
+				f.get(t, id)
+			}
+			if _, err := f.repo.FilterByCriteria(ctx, "default", dto.FilterByCriteriaData{}); err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range ids {
+				if _, fromDB := f.get(t, id); fromDB {
+					t.Fatalf("warm lookup of %s reached the database", id)
+				}
+			}
+
+			if err := tc.write(f.repo); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, id := range ids {
+				if _, fromDB := f.get(t, id); !fromDB {
+					t.Fatalf("lookup of %s after %s was served from the cache", id, tc.name)
+				}
+			}
+			reads := f.next.readCount()
+			if _, err := f.repo.FilterByCriteria(ctx, "default", dto.FilterByCriteriaData{}); err != nil {
+				t.Fatal(err)
+			}
+			if f.next.readCount() == reads {
+				t.Fatalf("filter after %s was served from the cache", tc.name)
+			}
+		})
+	}
+}
+
+func TestCachedEntryNotStoredAcrossInvalidation(t *testing.T) {
+	f := newCacheFixture(t)
+	ctx := context.Background()
+	if _, err := f.repo.CreateNewStringRecord(ctx, models.StringEntry{Collection: "default", ID: "a", Value: "a"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The row is deleted after the lookup read it but before it is cached
+	f.next.beforeReturn = func() {
+		if _, err := f.repo.DeleteStringValue(ctx, "default", "a", nil); err != nil {
+			t.Error(err)
+		}
+	}
+	if entry, _ := f.get(t, "a"); entry == nil {
+		t.Fatal("racing lookup did not return the row it read")
+	}
+
+	entry, fromDB := f.get(t, "a")
+	if !fromDB || entry != nil {
+		t.Fatalf("lookup after the delete returned %+v from the cache, want a database miss", entry)
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"task_one/models"
+
+	"gorm.io/gorm"
+)
+
+type CollectionRepository interface {
+	CreateCollection(ctx context.Context, collection models.Collection) (*models.Collection, error)
+	GetCollection(ctx context.Context, name string) (*models.Collection, error)
+	ListCollections(ctx context.Context) (*[]models.Collection, error)
+	CountStrings(ctx context.Context, name string) (int64, error)
+	DeleteCollection(ctx context.Context, name string) error
+}
+
+type collectionRepository struct {
+	db *gorm.DB
+}
+
+func NewCollectionRepository(db *gorm.DB, logger *slog.Logger) CollectionRepository {
+	return &collectionRepository{db: withLogger(db, logger, "collections")}
+}
+
+func (r collectionRepository) CreateCollection(ctx context.Context, collection models.Collection) (*models.Collection, error) {
+	if err := r.db.WithContext(ctx).Create(&collection).Error; err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &collection, nil
+}
+
+func (r collectionRepository) GetCollection(ctx context.Context, name string) (*models.Collection, error) {
+	var collection models.Collection
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&collection).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &collection, nil
+}
+
+func (r collectionRepository) ListCollections(ctx context.Context) (*[]models.Collection, error) {
+	var collections []models.Collection
+	if err := r.db.WithContext(ctx).Order("name").Find(&collections).Error; err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &collections, nil
+}
+
+// CountStrings counts every entry of the collection, including the trash
+func (r collectionRepository) CountStrings(ctx context.Context, name string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().
+		Model(&models.StringEntry{}).
+		Where("collection = ?", name).
+		Count(&count).Error
+	return count, contextError(ctx, err)
+}
+
+// DeleteCollection relies on the foreign key from string_entries, which
+// refuses the delete while any string, trashed or not, is in the collection,
+// including one created after the caller counted them
+func (r collectionRepository) DeleteCollection(ctx context.Context, name string) error {
+	err := r.db.WithContext(ctx).Where("name = ?", name).Delete(&models.Collection{}).Error
+	if isForeignKeyViolation(err) {
+		return fmt.Errorf("conflict: collection still holds strings")
+	}
+	return contextError(ctx, err)
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Errors returned in place of the driver's error when the caller's context
+// ended before the query finished
+var (
+	ErrCanceled = errors.New("canceled: the request was canceled")
+	ErrTimeout  = errors.New("timeout: the query exceeded its deadline")
+)
+
+// ErrPreconditionFailed is returned by a conditional write whose
+// precondition rejected the stored entry. Nothing was written.
+var ErrPreconditionFailed = errors.New("precondition failed: the string has changed")
+
+// contextError attributes err to ctx when ctx has ended, so callers can tell
+// an abandoned query from a failing database whatever the driver reports
+func contextError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("%w: %v", ErrCanceled, err)
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	}
+	return err
+}
+
+// isForeignKeyViolation reports whether err is Postgres refusing a write that
+// would leave a string outside any collection
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23503"
+}
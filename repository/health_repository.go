@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"task_one/migrations"
+
+	"gorm.io/gorm"
+)
+
+type HealthRepository interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+type healthRepository struct {
+	db *gorm.DB
+}
+
+func NewHealthRepository(db *gorm.DB, logger *slog.Logger) HealthRepository {
+	return &healthRepository{db: withLogger(db, logger, "health")}
+}
+
+func (r healthRepository) Ping(ctx context.Context) error {
+	sqlDB, err := r.db.DB()
+	if err != nil {
+		return err
+	}
+	return contextError(ctx, sqlDB.PingContext(ctx))
+}
+
+// SchemaVersion returns the highest applied migration, 0 before the first
+func (r healthRepository) SchemaVersion(ctx context.Context) (int, error) {
+	version, err := migrations.New(r.db).Current(ctx)
+	return version, contextError(ctx, err)
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"task_one/models"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, clientKey, key, fingerprint string, expiredBefore, abandonedBefore time.Time) (time.Time, bool, error)
+	Get(ctx context.Context, clientKey, key string) (*models.IdempotencyKey, error)
+	Complete(ctx context.Context, clientKey, key string, reservedAt time.Time, statusCode int, headers datatypes.JSON, body []byte) error
+	Release(ctx context.Context, clientKey, key string, reservedAt time.Time) error
+	PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyRepository(db *gorm.DB, logger *slog.Logger) IdempotencyRepository {
+	return &idempotencyRepository{db: withLogger(db, logger, "idempotency_keys")}
+}
+
+// Reserve claims key for a request that is about to run. A record created
+// before expiredBefore is taken over, as is a reservation made before
+// abandonedBefore whose request never completed. It reports false when the
+// key is already held. The returned time, stored as created_at, identifies
+// the reservation to Complete and Release; it is truncated to the precision
+// Postgres stores.
+func (r idempotencyRepository) Reserve(ctx context.Context, clientKey, key, fingerprint string, expiredBefore, abandonedBefore time.Time) (time.Time, bool, error) {
+	reservedAt := time.Now().UTC().Truncate(time.Microsecond)
+	result := r.db.WithContext(ctx).Exec(`
+		INSERT INTO idempotency_keys (client_key, key, fingerprint, created_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (client_key, key) DO UPDATE SET
+			fingerprint = excluded.fingerprint,
+			status_code = 0,
+			headers = '{}',
+			body = NULL,
+			created_at = excluded.created_at
+		WHERE idempotency_keys.created_at < ?
+			OR (idempotency_keys.status_code = 0 AND idempotency_keys.created_at < ?)`,
+		clientKey, key, fingerprint, reservedAt, expiredBefore, abandonedBefore)
+	if result.Error != nil {
+		return time.Time{}, false, contextError(ctx, result.Error)
+	}
+	return reservedAt, result.RowsAffected > 0, nil
+}
+
+func (r idempotencyRepository) Get(ctx context.Context, clientKey, key string) (*models.IdempotencyKey, error) {
+	var record models.IdempotencyKey
+	err := r.db.WithContext(ctx).Where("client_key = ? AND key = ?", clientKey, key).First(&record).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &record, nil
+}
+
+// Complete stores the response of the request that made the reservation at
+// reservedAt. Once a retry has taken the key over, the reservation is gone
+// and the retry's own outcome is kept.
+func (r idempotencyRepository) Complete(ctx context.Context, clientKey, key string, reservedAt time.Time, statusCode int, headers datatypes.JSON, body []byte) error {
+	err := r.db.WithContext(ctx).Model(&models.IdempotencyKey{}).
+		Where("client_key = ? AND key = ? AND created_at = ? AND status_code = 0", clientKey, key, reservedAt).
+		Updates(map[string]any{"status_code": statusCode, "headers": headers, "body": body}).Error
+	return contextError(ctx, err)
+}
+
+// Release drops a reservation whose request did not produce a response worth
+// keeping, so the client may retry with the same key. Like Complete it only
+// touches the reservation made at reservedAt.
+func (r idempotencyRepository) Release(ctx context.Context, clientKey, key string, reservedAt time.Time) error {
+	err := r.db.WithContext(ctx).
+		Where("client_key = ? AND key = ? AND created_at = ? AND status_code = 0", clientKey, key, reservedAt).
+		Delete(&models.IdempotencyKey{}).Error
+	return contextError(ctx, err)
+}
+
+func (r idempotencyRepository) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("created_at < ?", cutoff).Delete(&models.IdempotencyKey{})
+	if result.Error != nil {
+		return 0, contextError(ctx, result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"task_one/dto"
+	"task_one/metrics"
+	"task_one/models"
+	"time"
+
+	"gorm.io/datatypes"
+)
+
+// observeQuery records the latency and outcome of a repository call
+func observeQuery(m *metrics.Metrics, repository, method string, start time.Time, err error) {
+	outcome := metrics.QueryOK
+	switch {
+	case errors.Is(err, ErrCanceled):
+		outcome = metrics.QueryCanceled
+	case errors.Is(err, ErrTimeout):
+		outcome = metrics.QueryTimeout
+	// A rejected precondition is an answer, not a failing query
+	case err != nil && !errors.Is(err, ErrPreconditionFailed):
+		outcome = metrics.QueryError
+	}
+	m.ObserveQuery(repository, method, time.Since(start), outcome)
+}
+
+// instrumentedStringRepository records the latency of every call to the
+// wrapped repository
+type instrumentedStringRepository struct {
+	next    StringRepository
+	metrics *metrics.Metrics
+}
+
+func NewInstrumentedStringRepository(next StringRepository, m *metrics.Metrics) StringRepository {
+	return &instrumentedStringRepository{next: next, metrics: m}
+}
+
+func (r instrumentedStringRepository) observe(method string, start time.Time, err error) {
+	observeQuery(r.metrics, "strings", method, start, err)
+}
+
+func (r instrumentedStringRepository) CreateNewStringRecord(ctx context.Context, stringData models.StringEntry) (created bool, err error) {
+	start := time.Now()
+	defer func() { r.observe("CreateNewStringRecord", start, err) }()
+	return r.next.CreateNewStringRecord(ctx, stringData)
+}
+
+func (r instrumentedStringRepository) GetStringByHash(ctx context.Context, collection, hash string) (entry *models.StringEntry, err error) {
+	start := time.Now()
+	defer func() { r.observe("GetStringByHash", start, err) }()
+	return r.next.GetStringByHash(ctx, collection, hash)
+}
+
+func (r instrumentedStringRepository) GetStringById(ctx context.Context, collection, id string) (entry *models.StringEntry, err error) {
+	start := time.Now()
+	defer func() { r.observe("GetStringById", start, err) }()
+	return r.next.GetStringById(ctx, collection, id)
+}
+
+func (r instrumentedStringRepository) FilterByCriteria(ctx context.Context, collection string, input dto.FilterByCriteriaData) (entries *[]models.StringEntry, err error) {
+	start := time.Now()
+	defer func() { r.observe("FilterByCriteria", start, err) }()
+	return r.next.FilterByCriteria(ctx, collection, input)
+}
+
+func (r instrumentedStringRepository) ExplainFilter(ctx context.Context, collection string, input dto.FilterByCriteriaData, analyze bool) (plan *FilterPlan, err error) {
+	start := time.Now()
+	defer func() { r.observe("ExplainFilter", start, err) }()
+	return r.next.ExplainFilter(ctx, collection, input, analyze)
+}
+
+func (r instrumentedStringRepository) UpdateStringRecord(ctx context.Context, collection, hash string, updates map[string]any, precondition Precondition) (updated bool, err error) {
+	start := time.Now()
+	defer func() { r.observe("UpdateStringRecord", start, err) }()
+	return r.next.UpdateStringRecord(ctx, collection, hash, updates, precondition)
+}
+
+func (r instrumentedStringRepository) DeleteStringValue(ctx context.Context, collection, hash string, precondition Precondition) (deleted bool, err error) {
+	start := time.Now()
+	defer func() { r.observe("DeleteStringValue", start, err) }()
+	return r.next.DeleteStringValue(ctx, collection, hash, precondition)
+}
+
+func (r instrumentedStringRepository) ListDeleted(ctx context.Context, collection string) (entries *[]models.StringEntry, err error) {
+	start := time.Now()
+	defer func() { r.observe("ListDeleted", start, err) }()
+	return r.next.ListDeleted(ctx, collection)
+}
+
+func (r instrumentedStringRepository) RestoreStringValue(ctx context.Context, collection, hash string) (restored bool, err error) {
+	start := time.Now()
+	defer func() { r.observe("RestoreStringValue", start, err) }()
+	return r.next.RestoreStringValue(ctx, collection, hash)
+}
+
+func (r instrumentedStringRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (purged int64, err error) {
+	start := time.Now()
+	defer func() { r.observe("PurgeDeletedBefore", start, err) }()
+	return r.next.PurgeDeletedBefore(ctx, cutoff)
+}
+
+func (r instrumentedStringRepository) CountStale(ctx context.Context) (count int64, err error) {
+	start := time.Now()
+	defer func() { r.observe("CountStale", start, err) }()
+	return r.next.CountStale(ctx)
+}
+
+func (r instrumentedStringRepository) ListStale(ctx context.Context, after StaleCursor, limit int) (entries *[]models.StringEntry, err error) {
+	start := time.Now()
+	defer func() { r.observe("ListStale", start, err) }()
+	return r.next.ListStale(ctx, after, limit)
+}
+
+func (r instrumentedStringRepository) Stats(ctx context.Context, collection string, ngramSizes []int, top int) (stats *StringStats, err error) {
+	start := time.Now()
+	defer func() { r.observe("Stats", start, err) }()
+	return r.next.Stats(ctx, collection, ngramSizes, top)
+}
+
+func (r instrumentedStringRepository) SaveAnalysis(ctx context.Context, entries []models.StringEntry) (saved int64, err error) {
+	start := time.Now()
+	defer func() { r.observe("SaveAnalysis", start, err) }()
+	return r.next.SaveAnalysis(ctx, entries)
+}
+
+// instrumentedCollectionRepository records the latency of every call to the
+// wrapped repository
+type instrumentedCollectionRepository struct {
+	next    CollectionRepository
+	metrics *metrics.Metrics
+}
+
+func NewInstrumentedCollectionRepository(next CollectionRepository, m *metrics.Metrics) CollectionRepository {
+	return &instrumentedCollectionRepository{next: next, metrics: m}
+}
+
+func (r instrumentedCollectionRepository) observe(method string, start time.Time, err error) {
+	observeQuery(r.metrics, "collections", method, start, err)
+}
+
+func (r instrumentedCollectionRepository) CreateCollection(ctx context.Context, collection models.Collection) (created *models.Collection, err error) {
+	start := time.Now()
+	defer func() { r.observe("CreateCollection", start, err) }()
+	return r.next.CreateCollection(ctx, collection)
+}
+
+func (r instrumentedCollectionRepository) GetCollection(ctx context.Context, name string) (collection *models.Collection, err error) {
+	start := time.Now()
+	defer func() { r.observe("GetCollection", start, err) }()
+	return r.next.GetCollection(ctx, name)
+}
+
+func (r instrumentedCollectionRepository) ListCollections(ctx context.Context) (collections *[]models.Collection, err error) {
+	start := time.Now()
+	defer func() { r.observe("ListCollections", start, err) }()
+	return r.next.ListCollections(ctx)
+}
+
+func (r instrumentedCollectionRepository) CountStrings(ctx context.Context, name string) (count int64, err error) {
+	start := time.Now()
+	defer func() { r.observe("CountStrings", start, err) }()
+	return r.next.CountStrings(ctx, name)
+}
+
+func (r instrumentedCollectionRepository) DeleteCollection(ctx context.Context, name string) (err error) {
+	start := time.Now()
+	defer func() { r.observe("DeleteCollection", start, err) }()
+	return r.next.DeleteCollection(ctx, name)
+}
+
+// instrumentedAPIKeyRepository records the latency of every call to the
+// wrapped repository
+type instrumentedAPIKeyRepository struct {
+	next    APIKeyRepository
+	metrics *metrics.Metrics
+}
+
+func NewInstrumentedAPIKeyRepository(next APIKeyRepository, m *metrics.Metrics) APIKeyRepository {
+	return &instrumentedAPIKeyRepository{next: next, metrics: m}
+}
+
+func (r instrumentedAPIKeyRepository) observe(method string, start time.Time, err error) {
+	observeQuery(r.metrics, "api_keys", method, start, err)
+}
+
+func (r instrumentedAPIKeyRepository) CreateAPIKey(ctx context.Context, key models.APIKey) (created *models.APIKey, err error) {
+	start := time.Now()
+	defer func() { r.observe("CreateAPIKey", start, err) }()
+	return r.next.CreateAPIKey(ctx, key)
+}
+
+func (r instrumentedAPIKeyRepository) GetAPIKeyByHash(ctx context.Context, keyHash string) (key *models.APIKey, err error) {
+	start := time.Now()
+	defer func() { r.observe("GetAPIKeyByHash", start, err) }()
+	return r.next.GetAPIKeyByHash(ctx, keyHash)
+}
+
+func (r instrumentedAPIKeyRepository) ListAPIKeys(ctx context.Context) (keys *[]models.APIKey, err error) {
+	start := time.Now()
+	defer func() { r.observe("ListAPIKeys", start, err) }()
+	return r.next.ListAPIKeys(ctx)
+}
+
+func (r instrumentedAPIKeyRepository) RevokeAPIKey(ctx context.Context, id string, revokedAt time.Time) (revoked bool, err error) {
+	start := time.Now()
+	defer func() { r.observe("RevokeAPIKey", start, err) }()
+	return r.next.RevokeAPIKey(ctx, id, revokedAt)
+}
+
+func (r instrumentedAPIKeyRepository) TouchAPIKey(ctx context.Context, id string, usedAt time.Time) (err error) {
+	start := time.Now()
+	defer func() { r.observe("TouchAPIKey", start, err) }()
+	return r.next.TouchAPIKey(ctx, id, usedAt)
+}
+
+// instrumentedIdempotencyRepository records the latency of every call to the
+// wrapped repository
+type instrumentedIdempotencyRepository struct {
+	next    IdempotencyRepository
+	metrics *metrics.Metrics
+}
+
+func NewInstrumentedIdempotencyRepository(next IdempotencyRepository, m *metrics.Metrics) IdempotencyRepository {
+	return &instrumentedIdempotencyRepository{next: next, metrics: m}
+}
+
+func (r instrumentedIdempotencyRepository) observe(method string, start time.Time, err error) {
+	observeQuery(r.metrics, "idempotency_keys", method, start, err)
+}
+
+func (r instrumentedIdempotencyRepository) Reserve(ctx context.Context, clientKey, key, fingerprint string, expiredBefore, abandonedBefore time.Time) (reservedAt time.Time, reserved bool, err error) {
+	start := time.Now()
+	defer func() { r.observe("Reserve", start, err) }()
+	return r.next.Reserve(ctx, clientKey, key, fingerprint, expiredBefore, abandonedBefore)
+}
+
+func (r instrumentedIdempotencyRepository) Get(ctx context.Context, clientKey, key string) (record *models.IdempotencyKey, err error) {
+	start := time.Now()
+	defer func() { r.observe("Get", start, err) }()
+	return r.next.Get(ctx, clientKey, key)
+}
+
+func (r instrumentedIdempotencyRepository) Complete(ctx context.Context, clientKey, key string, reservedAt time.Time, statusCode int, headers datatypes.JSON, body []byte) (err error) {
+	start := time.Now()
+	defer func() { r.observe("Complete", start, err) }()
+	return r.next.Complete(ctx, clientKey, key, reservedAt, statusCode, headers, body)
+}
+
+func (r instrumentedIdempotencyRepository) Release(ctx context.Context, clientKey, key string, reservedAt time.Time) (err error) {
+	start := time.Now()
+	defer func() { r.observe("Release", start, err) }()
+	return r.next.Release(ctx, clientKey, key, reservedAt)
+}
+
+func (r instrumentedIdempotencyRepository) PurgeBefore(ctx context.Context, cutoff time.Time) (purged int64, err error) {
+	start := time.Now()
+	defer func() { r.observe("PurgeBefore", start, err) }()
+	return r.next.PurgeBefore(ctx, cutoff)
+}
@@ -0,0 +1,14 @@
+package repository
+
+import (
+	"log/slog"
+	"task_one/logging"
+
+	"gorm.io/gorm"
+)
+
+// withLogger returns a session of db whose statements are logged through
+// logger, tagged with the repository name
+func withLogger(db *gorm.DB, logger *slog.Logger, name string) *gorm.DB {
+	return db.Session(&gorm.Session{Logger: logging.Gorm(logger.With(slog.String("repository", name)))})
+}
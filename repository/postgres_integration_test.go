@@ -0,0 +1,323 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"task_one/analysis"
+	"task_one/dto"
+	"task_one/migrations"
+	"task_one/models"
+
+	"gorm.io/datatypes"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// These tests run the repositories' SQL against a real Postgres:
+//
+//	TEST_DATABASE_URL=postgres://... go test -tags integration ./repository
+//
+// The database is migrated to the latest version. Each test works in a
+// collection of its own and removes it afterwards.
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrations.New(db).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+// newTestCollection creates a collection named after the test and deletes it
+// with its strings when the test ends
+func newTestCollection(t *testing.T, db *gorm.DB) string {
+	t.Helper()
+	name := fmt.Sprintf("it-%s-%d", strings.ToLower(strings.ReplaceAll(t.Name(), "/", "-")), time.Now().UnixNano())
+	if err := db.Create(&models.Collection{Name: name}).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Unscoped().Where("collection = ?", name).Delete(&models.StringEntry{})
+		db.Where("name = ?", name).Delete(&models.Collection{})
+	})
+	return name
+}
+
+func testEntry(collection, value string) models.StringEntry {
+	return models.StringEntry{
+		Collection:            collection,
+		ID:                    value,
+		Value:                 value,
+		Length:                len(value),
+		SHA256Hash:            value,
+		CharacterFrequencyMap: datatypes.JSON(`{}`),
+	}
+}
+
+// raceCreates runs CreateNewStringRecord for entry from several goroutines at
+// once and returns how many reported a new entry
+func raceCreates(t *testing.T, repo StringRepository, entry models.StringEntry) int {
+	t.Helper()
+	const writers = 8
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		created int
+	)
+	start := make(chan struct{})
+	for range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			ok, err := repo.CreateNewStringRecord(context.Background(), entry)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if ok {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return created
+}
+
+func TestCreateNewStringRecordRace(t *testing.T) {
+	db := openTestDB(t)
+	collection := newTestCollection(t, db)
+	repo := NewStringRepository(db, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+
+	if created := raceCreates(t, repo, testEntry(collection, "race")); created != 1 {
+		t.Fatalf("%d concurrent creates succeeded, want 1", created)
+	}
+
+	// A trashed entry is revived by exactly one of the racing creates, via
+	// the ON CONFLICT ... WHERE deleted_at IS NOT NULL branch
+	if deleted, err := repo.DeleteStringValue(ctx, collection, "race", nil); err != nil || !deleted {
+		t.Fatalf("delete: %v, %v", deleted, err)
+	}
+	revived := testEntry(collection, "race")
+	revived.CreatedBy = "reviver"
+	if created := raceCreates(t, repo, revived); created != 1 {
+		t.Fatalf("%d concurrent creates revived the trashed entry, want 1", created)
+	}
+	entry, err := repo.GetStringById(ctx, collection, "race")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || entry.CreatedBy != "reviver" || entry.DeletedAt.Valid {
+		t.Fatalf("revived entry %+v, want a live entry created by reviver", entry)
+	}
+}
+
+func TestCollectionForeignKey(t *testing.T) {
+	db := openTestDB(t)
+	collection := newTestCollection(t, db)
+	stringRepo := NewStringRepository(db, slog.New(slog.DiscardHandler))
+	collectionRepo := NewCollectionRepository(db, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+
+	if _, err := stringRepo.CreateNewStringRecord(ctx, testEntry(collection, "held")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stringRepo.DeleteStringValue(ctx, collection, "held", nil); err != nil {
+		t.Fatal(err)
+	}
+	// A trashed string still holds the collection
+	if err := collectionRepo.DeleteCollection(ctx, collection); err == nil || !contains(err, "conflict") {
+		t.Fatalf("deleting a collection holding a string: got %v, want a conflict", err)
+	}
+
+	if _, err := stringRepo.CreateNewStringRecord(ctx, testEntry(collection+"-missing", "orphan")); err == nil || !contains(err, "not found") {
+		t.Fatalf("creating a string in a missing collection: got %v, want not found", err)
+	}
+}
+
+func TestIdempotencyReservationToken(t *testing.T) {
+	db := openTestDB(t)
+	repo := NewIdempotencyRepository(db, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+	clientKey := fmt.Sprintf("it-%d", time.Now().UnixNano())
+	t.Cleanup(func() { db.Where("client_key = ?", clientKey).Delete(&models.IdempotencyKey{}) })
+
+	original, reserved, err := repo.Reserve(ctx, clientKey, "k", "f", time.Time{}, time.Time{})
+	if err != nil || !reserved {
+		t.Fatalf("reserve: %v, %v", reserved, err)
+	}
+	// The original's lease runs out and a retry takes the key over
+	future := time.Now().Add(time.Hour)
+	retry, reserved, err := repo.Reserve(ctx, clientKey, "k", "f", time.Time{}, future)
+	if err != nil || !reserved || retry.Equal(original) {
+		t.Fatalf("takeover: %v, %v, same token %v", reserved, err, retry.Equal(original))
+	}
+
+	if err := repo.Complete(ctx, clientKey, "k", original, 201, datatypes.JSON(`{}`), []byte("original")); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.Release(ctx, clientKey, "k", original); err != nil {
+		t.Fatal(err)
+	}
+	record, err := repo.Get(ctx, clientKey, "k")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.StatusCode != 0 {
+		t.Fatalf("the original's late Complete or Release touched the retry's reservation: %+v", record)
+	}
+
+	if err := repo.Complete(ctx, clientKey, "k", retry, 201, datatypes.JSON(`{}`), []byte("retry")); err != nil {
+		t.Fatal(err)
+	}
+	if record, err = repo.Get(ctx, clientKey, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if record == nil || record.StatusCode != 201 || string(record.Body) != "retry" {
+		t.Fatalf("stored %+v, want the retry's response", record)
+	}
+}
+
+func contains(err error, substr string) bool {
+	return strings.Contains(err.Error(), substr)
+}
+
+func TestCollectionVersionBumpedOncePerTransaction(t *testing.T) {
+	db := openTestDB(t)
+	collection := newTestCollection(t, db)
+	ctx := context.Background()
+	version := func(tx *gorm.DB) int64 {
+		t.Helper()
+		var found models.Collection
+		if err := tx.Where("name = ?", collection).First(&found).Error; err != nil {
+			t.Fatal(err)
+		}
+		return found.Version
+	}
+
+	before := version(db)
+	var during []int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, value := range []string{"one", "two"} {
+			entry := testEntry(collection, value)
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+			during = append(during, version(tx))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if during[0] == before || during[1] != during[0] {
+		t.Fatalf("versions %d then %v within one transaction, want one bump", before, during)
+	}
+
+	if _, err := NewStringRepository(db, slog.New(slog.DiscardHandler)).DeleteStringValue(ctx, collection, "one", nil); err != nil {
+		t.Fatal(err)
+	}
+	if after := version(db); after == during[1] {
+		t.Fatal("a write in a later transaction did not bump the version")
+	}
+}
+
+func TestStatsAndContainsNGram(t *testing.T) {
+	db := openTestDB(t)
+	collection := newTestCollection(t, db)
+	repo := NewStringRepository(db, slog.New(slog.DiscardHandler))
+	ctx := context.Background()
+	if _, ok := analysis.Default().Lookup(analysis.NGramPropertyName(2)); !ok {
+		if err := analysis.RegisterNGrams([]int{2}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bigrams := map[string]string{
+		"abab": `{"ab": 2, "ba": 1}`,
+		"äöab": `{"äö": 1, "öa": 1, "ab": 1}`,
+		"old":  ``,
+		"gone": `{"ab": 5}`,
+	}
+	for value, counts := range bigrams {
+		entry := testEntry(collection, value)
+		entry.Properties = datatypes.JSON(`{}`)
+		if counts != "" {
+			entry.Properties = datatypes.JSON(`{"` + analysis.NGramPropertyName(2) + `": ` + counts + `}`)
+		}
+		if _, err := repo.CreateNewStringRecord(ctx, entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := repo.DeleteStringValue(ctx, collection, "gone", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Trashed entries and entries without the size do not count
+	stats, err := repo.Stats(ctx, collection, []int{2}, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []NGramCount{{NGram: "ab", Occurrences: 3, Strings: 2}, {NGram: "ba", Occurrences: 1, Strings: 1}}
+	if stats.Count != 3 || !slices.Equal(stats.TopNGrams[2], want) {
+		t.Fatalf("stats %+v, want 3 strings and top bigrams %+v", stats, want)
+	}
+
+	tests := []struct {
+		ngram string
+		want  []string
+	}{
+		{"AB", []string{"abab", "äöab"}},
+		{"ÄÖ", []string{"äöab"}},
+		{"zz", nil},
+		// A key that would need escaping in jsonpath
+		{`"\`, nil},
+	}
+	for _, tt := range tests {
+		entries, err := repo.FilterByCriteria(ctx, collection, dto.FilterByCriteriaData{ContainsNGram: &tt.ngram})
+		if err != nil {
+			t.Fatalf("contains_ngram=%s: %v", tt.ngram, err)
+		}
+		var got []string
+		for _, entry := range *entries {
+			got = append(got, entry.Value)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("contains_ngram=%s: found %q, want %q", tt.ngram, got, tt.want)
+		}
+	}
+
+	trigram := "abc"
+	if _, err := repo.FilterByCriteria(ctx, collection, dto.FilterByCriteriaData{ContainsNGram: &trigram}); err == nil || !contains(err, "invalid filter") {
+		t.Fatalf("contains_ngram of an uncounted size: got %v, want invalid filter", err)
+	}
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"task_one/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type QuotaRepository interface {
+	IncrementDailyCount(ctx context.Context, clientKey string, day time.Time) (int64, error)
+	DecrementDailyCount(ctx context.Context, clientKey string, day time.Time) error
+}
+
+type quotaRepository struct {
+	db *gorm.DB
+}
+
+func NewQuotaRepository(db *gorm.DB, logger *slog.Logger) QuotaRepository {
+	return &quotaRepository{db: withLogger(db, logger, "quotas")}
+}
+
+// IncrementDailyCount atomically bumps the client's counter for day and
+// returns the new count
+func (r quotaRepository) IncrementDailyCount(ctx context.Context, clientKey string, day time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Raw(`
+		INSERT INTO client_quotas (client_key, day, count) VALUES (?, ?, 1)
+		ON CONFLICT (client_key, day) DO UPDATE SET count = client_quotas.count + 1
+		RETURNING count`, clientKey, day).Scan(&count).Error
+	return count, contextError(ctx, err)
+}
+
+func (r quotaRepository) DecrementDailyCount(ctx context.Context, clientKey string, day time.Time) error {
+	err := r.db.WithContext(ctx).Model(&models.ClientQuota{}).
+		Where("client_key = ? AND day = ? AND count > 0", clientKey, day).
+		Update("count", gorm.Expr("count - 1")).Error
+	return contextError(ctx, err)
+}
@@ -1,63 +1,138 @@
 package repository
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"task_one/analysis"
 	"task_one/dto"
 	"task_one/models"
+	"time"
+	"unicode/utf8"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type StringRepository interface {
-	CreateNewStringRecord(stringData models.StringEntry) (*models.StringEntry, error)
-	GetStringByValue(value string) (*models.StringEntry, error)
-	GetStringById(id string) (*models.StringEntry, error)
-	FilterByCriteria(input dto.FilterByCriteriaData) (*[]models.StringEntry, error)
-	DeleteStringValue(hash string) error
+	CreateNewStringRecord(ctx context.Context, stringData models.StringEntry) (bool, error)
+	GetStringByHash(ctx context.Context, collection, hash string) (*models.StringEntry, error)
+	GetStringById(ctx context.Context, collection, id string) (*models.StringEntry, error)
+	FilterByCriteria(ctx context.Context, collection string, input dto.FilterByCriteriaData) (*[]models.StringEntry, error)
+	ExplainFilter(ctx context.Context, collection string, input dto.FilterByCriteriaData, analyze bool) (*FilterPlan, error)
+	UpdateStringRecord(ctx context.Context, collection, hash string, updates map[string]any, precondition Precondition) (bool, error)
+	DeleteStringValue(ctx context.Context, collection, hash string, precondition Precondition) (bool, error)
+	ListDeleted(ctx context.Context, collection string) (*[]models.StringEntry, error)
+	RestoreStringValue(ctx context.Context, collection, hash string) (bool, error)
+	PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	CountStale(ctx context.Context) (int64, error)
+	ListStale(ctx context.Context, after StaleCursor, limit int) (*[]models.StringEntry, error)
+	SaveAnalysis(ctx context.Context, entries []models.StringEntry) (int64, error)
+	Stats(ctx context.Context, collection string, ngramSizes []int, top int) (*StringStats, error)
+}
+
+// StaleCursor is the position of a page through entries analyzed by older
+// versions. The zero value starts at the beginning.
+type StaleCursor struct {
+	AnalyzerVersion int
+	Collection      string
+	ID              string
 }
 
 type stringRepository struct {
 	db *gorm.DB
 }
 
-func NewStringRepository(db *gorm.DB) StringRepository {
-	return &stringRepository{db: db}
+func NewStringRepository(db *gorm.DB, logger *slog.Logger) StringRepository {
+	return &stringRepository{db: withLogger(db, logger, "strings")}
 }
 
-func (r stringRepository) GetStringByValue(value string) (*models.StringEntry, error) {
+// GetStringByHash finds an entry by the SHA-256 hash of its value. The hash
+// is the entry's id, so the lookup goes through the primary key.
+func (r stringRepository) GetStringByHash(ctx context.Context, collection, hash string) (*models.StringEntry, error) {
 	var entry models.StringEntry
-	err := r.db.Where("value = ?", value).First(&entry).Error
+	err := r.db.WithContext(ctx).Where("collection = ? AND id = ?", collection, hash).First(&entry).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, err)
 	}
 	return &entry, nil
 }
 
-func (r stringRepository) GetStringById(id string) (*models.StringEntry, error) {
+func (r stringRepository) GetStringById(ctx context.Context, collection, id string) (*models.StringEntry, error) {
 	var entry models.StringEntry
-	err := r.db.Where("id = ?", id).First(&entry).Error
+	err := r.db.WithContext(ctx).Where("collection = ? AND id = ?", collection, id).First(&entry).Error
 	if errors.Is(err, gorm.ErrRecordNotFound) {
 		return nil, nil
 	}
 	if err != nil {
-		return nil, err
+		return nil, contextError(ctx, err)
 	}
 	return &entry, nil
 }
 
-func (r stringRepository) CreateNewStringRecord(stringData models.StringEntry) (*models.StringEntry, error) {
-	if err := r.db.Create(&stringData).Error; err != nil {
+// CreateNewStringRecord stores stringData in a single statement, so
+// concurrent creations of one value cannot both succeed. A trashed entry with
+// the same id is replaced in place. It reports false, storing nothing, when a
+// live entry already holds the id.
+func (r stringRepository) CreateNewStringRecord(ctx context.Context, stringData models.StringEntry) (bool, error) {
+	columns, err := replaceableColumns(r.db)
+	if err != nil {
+		return false, err
+	}
+	result := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "collection"}, {Name: "id"}},
+		DoUpdates: clause.AssignmentColumns(columns),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: "string_entries.deleted_at IS NOT NULL"}}},
+	}).Create(&stringData)
+	if isForeignKeyViolation(result.Error) {
+		return false, fmt.Errorf("not found: collection does not exist")
+	}
+	if result.Error != nil {
+		return false, contextError(ctx, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// replaceableColumns lists every string_entries column outside the primary
+// key, which is everything a revived entry overwrites
+func replaceableColumns(db *gorm.DB) ([]string, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&models.StringEntry{}); err != nil {
 		return nil, err
 	}
-	return &stringData, nil
+	var columns []string
+	for _, field := range stmt.Schema.Fields {
+		if field.DBName != "" && !field.PrimaryKey {
+			columns = append(columns, field.DBName)
+		}
+	}
+	return columns, nil
 }
 
-func (r stringRepository) FilterByCriteria(input dto.FilterByCriteriaData) (*[]models.StringEntry, error) {
+func (r stringRepository) FilterByCriteria(ctx context.Context, collection string, input dto.FilterByCriteriaData) (*[]models.StringEntry, error) {
+	query, err := r.filterQuery(r.db.WithContext(ctx), collection, input)
+	if err != nil {
+		return nil, err
+	}
+
 	var entries []models.StringEntry
-	query := r.db.Model(&models.StringEntry{})
+	if err := query.Find(&entries).Error; err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &entries, nil
+}
+
+// filterQuery selects the live entries of collection matching input
+func (r stringRepository) filterQuery(db *gorm.DB, collection string, input dto.FilterByCriteriaData) (*gorm.DB, error) {
+	query := db.Model(&models.StringEntry{}).Where("collection = ?", collection)
 
 	// Add conditions only if the filter values are provided
 	if input.IsPalindrome != nil {
@@ -72,21 +147,340 @@ func (r stringRepository) FilterByCriteria(input dto.FilterByCriteriaData) (*[]m
 	if input.WordCount != nil {
 		query = query.Where("word_count = ?", *input.WordCount)
 	}
+	if input.AnalyzerVersion != nil {
+		query = query.Where("analyzer_version = ?", *input.AnalyzerVersion)
+	}
+	if input.Stale != nil {
+		if *input.Stale {
+			query = query.Where(staleCondition())
+		} else {
+			query = query.Not(staleCondition())
+		}
+	}
+
+	// Statistic names double as column names; only known ones are accepted.
+	// Rows whose statistics have not been recomputed never match.
+	for name := range input.Ranges {
+		if !slices.Contains(models.StatisticColumns, name) {
+			return nil, fmt.Errorf("invalid filter: unknown statistic %q", name)
+		}
+	}
+	for _, column := range models.StatisticColumns {
+		bounds, ok := input.Ranges[column]
+		if !ok {
+			continue
+		}
+		if bounds.Min != nil {
+			query = query.Where(column+" >= ?", *bounds.Min)
+		}
+		if bounds.Max != nil {
+			query = query.Where(column+" <= ?", *bounds.Max)
+		}
+	}
+
+	// Registered analyzers are filtered by the kind of value they compute
+	for _, name := range slices.Sorted(maps.Keys(input.Properties)) {
+		var err error
+		query, err = propertyFilter(query, name, input.Properties[name])
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	// Check if JSONB field contains a specific key
+	// Key existence as a jsonpath predicate, which unlike -> can use the GIN
+	// index. The ? operator would clash with GORM's placeholders.
 	if input.ContainsCharacter != nil {
-		query = query.Where("character_frequency_map -> ? IS NOT NULL", *input.ContainsCharacter)
+		query = query.Where("character_frequency_map @@ ?::jsonpath", jsonPathKeyExists(*input.ContainsCharacter))
+	}
+	if input.ContainsNGram != nil {
+		// N-grams are counted lowercased, in the map for their length
+		ngram := strings.ToLower(*input.ContainsNGram)
+		name := analysis.NGramPropertyName(utf8.RuneCountInString(ngram))
+		if _, ok := analysis.Default().Lookup(name); !ok {
+			return nil, fmt.Errorf("invalid filter: n-grams of %d characters are not counted", utf8.RuneCountInString(ngram))
+		}
+		query = query.Where("properties @@ ?::jsonpath", jsonPathKeyExists(name, ngram))
 	}
 
-	if err := query.Find(&entries).Error; err != nil {
+	// Every requested tag must be present in the JSONB tags array
+	if len(input.Tags) > 0 {
+		tagsJSON, err := json.Marshal(input.Tags)
+		if err != nil {
+			return nil, err
+		}
+		query = query.Where("tags @> ?::jsonb", string(tagsJSON))
+	}
+	for key, value := range input.Metadata {
+		query = query.Where("metadata ->> ? = ?", key, value)
+	}
+	return query, nil
+}
+
+// propertyFilter narrows query to entries whose property name matches filter.
+// Entries analyzed before the analyzer was registered never match.
+func propertyFilter(query *gorm.DB, name string, filter dto.PropertyFilter) (*gorm.DB, error) {
+	analyzer, ok := analysis.Default().Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid filter: unknown property %q", name)
+	}
+	if (filter.Min != nil || filter.Max != nil) && analyzer.Kind() != analysis.KindNumber {
+		return nil, fmt.Errorf("invalid filter: property %q is not a number", name)
+	}
+
+	if filter.Equals != nil {
+		if analyzer.Kind() == analysis.KindObject {
+			key, ok := filter.Equals.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid filter: property %q takes a key", name)
+			}
+			query = query.Where("properties @@ ?::jsonpath", jsonPathKeyExists(name, key))
+		} else {
+			document, err := json.Marshal(map[string]any{name: filter.Equals})
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where("properties @> ?::jsonb", string(document))
+		}
+	}
+	if filter.Min != nil {
+		query = query.Where("(properties ->> ?)::numeric >= ?", name, *filter.Min)
+	}
+	if filter.Max != nil {
+		query = query.Where("(properties ->> ?)::numeric <= ?", name, *filter.Max)
+	}
+	return query, nil
+}
+
+// FilterPlan is the query behind a filter and PostgreSQL's plan for it, in
+// EXPLAIN's JSON format
+type FilterPlan struct {
+	Query string
+	Plan  json.RawMessage
+}
+
+// ExplainFilter plans the query FilterByCriteria would run for input. With
+// analyze the query is executed to report actual row counts and timings.
+func (r stringRepository) ExplainFilter(ctx context.Context, collection string, input dto.FilterByCriteriaData, analyze bool) (*FilterPlan, error) {
+	query, err := r.filterQuery(r.db.WithContext(ctx), collection, input)
+	if err != nil {
 		return nil, err
 	}
+	dryRun := query.Session(&gorm.Session{DryRun: true}).Find(&[]models.StringEntry{})
+	options := "FORMAT JSON"
+	if analyze {
+		options = "ANALYZE, BUFFERS, FORMAT JSON"
+	}
+
+	var plan string
+	err = r.db.WithContext(ctx).Raw("EXPLAIN ("+options+") ?", query).Row().Scan(&plan)
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &FilterPlan{Query: dryRun.Statement.SQL.String(), Plan: json.RawMessage(plan)}, nil
+}
+
+// staleCondition matches entries whose built-in properties come from an
+// older analysis, or that lack the current result of a registered analyzer
+func staleCondition() clause.Expr {
+	versions, _ := json.Marshal(analysis.Default().Versions())
+	return gorm.Expr("(analyzer_version < ? OR NOT property_versions @> ?::jsonb)", models.CurrentAnalyzerVersion, string(versions))
+}
+
+// CountStale counts entries, trashed ones included, that need a recompute
+func (r stringRepository) CountStale(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.StringEntry{}).
+		Where(staleCondition()).
+		Count(&count).Error
+	return count, contextError(ctx, err)
+}
+
+// ListStale pages through entries, trashed ones included, that need a
+// recompute. Pages follow the analyzer version index, so each one costs the
+// same however far the cursor has moved.
+func (r stringRepository) ListStale(ctx context.Context, after StaleCursor, limit int) (*[]models.StringEntry, error) {
+	var entries []models.StringEntry
+	err := r.db.WithContext(ctx).Unscoped().
+		Where(staleCondition()).
+		Where("(analyzer_version, collection, id) > (?, ?, ?)", after.AnalyzerVersion, after.Collection, after.ID).
+		Order("analyzer_version, collection, id").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
 	return &entries, nil
 }
 
-func (r stringRepository) DeleteStringValue(hash string) error {
-	if err := r.db.Where("id = ?", hash).Delete(&models.StringEntry{}).Error; err != nil {
-		return err
+// SaveAnalysis replaces the analysis columns of entries in one transaction.
+// An entry that is no longer stale is left alone, so concurrent recomputes
+// write each row once. It returns the number of rows changed.
+func (r stringRepository) SaveAnalysis(ctx context.Context, entries []models.StringEntry) (int64, error) {
+	var saved int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			result := tx.Unscoped().Model(&models.StringEntry{}).
+				Where("collection = ? AND id = ?", entry.Collection, entry.ID).
+				Where(staleCondition()).
+				Select(models.AnalysisColumns).
+				Updates(&entry)
+			if result.Error != nil {
+				return result.Error
+			}
+			saved += result.RowsAffected
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, contextError(ctx, err)
+	}
+	return saved, nil
+}
+
+// StringStats summarises the live entries of a collection
+type StringStats struct {
+	Count int64
+	// TopNGrams maps an n-gram size to its most frequent n-grams
+	TopNGrams map[int][]NGramCount
+}
+
+// NGramCount is the number of occurrences of an n-gram and the number of
+// entries it occurs in
+type NGramCount struct {
+	NGram       string `gorm:"column:ngram"`
+	Occurrences int64
+	Strings     int64
+}
+
+// Stats counts the live entries of collection and sums the n-gram maps of
+// each size in ngramSizes, keeping the top most frequent n-grams. Entries
+// analyzed before a size was counted do not contribute to it.
+func (r stringRepository) Stats(ctx context.Context, collection string, ngramSizes []int, top int) (*StringStats, error) {
+	stats := StringStats{TopNGrams: make(map[int][]NGramCount, len(ngramSizes))}
+	err := r.db.WithContext(ctx).Model(&models.StringEntry{}).Where("collection = ?", collection).Count(&stats.Count).Error
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+
+	for _, n := range ngramSizes {
+		counts := []NGramCount{}
+		err := r.db.WithContext(ctx).Raw(`SELECT ngram.key AS ngram, sum(ngram.value::bigint) AS occurrences, count(*) AS strings
+			FROM string_entries CROSS JOIN LATERAL jsonb_each_text(properties -> ?) AS ngram
+			WHERE collection = ? AND deleted_at IS NULL
+			GROUP BY ngram.key
+			ORDER BY occurrences DESC, ngram.key
+			LIMIT ?`, analysis.NGramPropertyName(n), collection, top).
+			Scan(&counts).Error
+		if err != nil {
+			return nil, contextError(ctx, err)
+		}
+		stats.TopNGrams[n] = counts
+	}
+	return &stats, nil
+}
+
+// jsonPathKeyExists builds the jsonpath predicate testing for the path of
+// keys in a document. Keys are quoted as JSON strings, which jsonpath
+// accepts.
+func jsonPathKeyExists(keys ...string) string {
+	path := "$"
+	for _, key := range keys {
+		quoted, _ := json.Marshal(key)
+		path += "." + string(quoted)
+	}
+	return "exists(" + path + ")"
+}
+
+// Precondition decides whether a conditional write may go ahead, given the
+// entry as stored. It runs while the entry's row is locked, so the entry
+// cannot change before the write.
+type Precondition func(current models.StringEntry) bool
+
+// UpdateStringRecord reports false when there is no live entry to update
+func (r stringRepository) UpdateStringRecord(ctx context.Context, collection, hash string, updates map[string]any, precondition Precondition) (bool, error) {
+	return r.writeEntry(ctx, collection, hash, precondition, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&models.StringEntry{}).Where("collection = ? AND id = ?", collection, hash).Updates(updates)
+	})
+}
+
+// DeleteStringValue moves the entry to the trash, reporting false when there
+// is no live entry to delete
+func (r stringRepository) DeleteStringValue(ctx context.Context, collection, hash string, precondition Precondition) (bool, error) {
+	return r.writeEntry(ctx, collection, hash, precondition, func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("collection = ? AND id = ?", collection, hash).Delete(&models.StringEntry{})
+	})
+}
+
+// writeEntry runs write against a single live entry and reports whether it
+// changed a row. With a precondition, the entry is locked with SELECT ... FOR
+// UPDATE and write runs in the same transaction only when precondition
+// accepts it, so the check and the write see the same row.
+func (r stringRepository) writeEntry(ctx context.Context, collection, hash string, precondition Precondition, write func(tx *gorm.DB) *gorm.DB) (bool, error) {
+	if precondition == nil {
+		result := write(r.db.WithContext(ctx))
+		if result.Error != nil {
+			return false, contextError(ctx, result.Error)
+		}
+		return result.RowsAffected > 0, nil
+	}
+
+	var written bool
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var current models.StringEntry
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("collection = ? AND id = ?", collection, hash).
+			First(&current).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !precondition(current) {
+			return ErrPreconditionFailed
+		}
+		result := write(tx)
+		written = result.RowsAffected > 0
+		return result.Error
+	})
+	if errors.Is(err, ErrPreconditionFailed) {
+		return false, err
+	}
+	if err != nil {
+		return false, contextError(ctx, err)
+	}
+	return written, nil
+}
+
+func (r stringRepository) ListDeleted(ctx context.Context, collection string) (*[]models.StringEntry, error) {
+	var entries []models.StringEntry
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("collection = ? AND deleted_at IS NOT NULL", collection).
+		Order("deleted_at DESC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, contextError(ctx, err)
+	}
+	return &entries, nil
+}
+
+func (r stringRepository) RestoreStringValue(ctx context.Context, collection, hash string) (bool, error) {
+	result := r.db.WithContext(ctx).Unscoped().
+		Model(&models.StringEntry{}).
+		Where("collection = ? AND id = ? AND deleted_at IS NOT NULL", collection, hash).
+		Update("deleted_at", nil)
+	if result.Error != nil {
+		return false, contextError(ctx, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r stringRepository) PurgeDeletedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Delete(&models.StringEntry{})
+	if result.Error != nil {
+		return 0, contextError(ctx, result.Error)
 	}
-	return nil
+	return result.RowsAffected, nil
 }
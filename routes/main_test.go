@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"os"
+	"testing"
+
+	"task_one/analysis"
+)
+
+// TestMain registers bigrams, as NGRAM_SIZES=2 would, so entries carry a
+// property group that responses only include on request
+func TestMain(m *testing.M) {
+	if err := analysis.RegisterNGrams([]int{2}); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"task_one/handlers"
+	"task_one/metrics"
+	"task_one/middleware"
+	"task_one/repository"
+	"task_one/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsScrapeAfterRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.DiscardHandler)
+	registry := prometheus.NewRegistry()
+	m := metrics.New(registry)
+
+	stringRepo := repository.NewInstrumentedStringRepository(newFakeStringRepository(), m)
+	idempotencyRepo := repository.NewInstrumentedIdempotencyRepository(newFakeIdempotencyRepository(), m)
+	stringService := services.NewStringService(stringRepo, nil, services.ValuePolicy{}, m, logger)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, time.Hour, time.Minute, logger)
+
+	router := gin.New()
+	registerObservability(router, m, logger)
+	registerProbeRoutes(router, handlers.NewHealthHandler(nil), registry)
+	api := router.Group("", middleware.Authenticate(nil, nil, false, logger))
+	registerStringRoutes(api, handlers.NewStringsHandler(stringService, nil, logger), stringRouteLimits{
+		read:        pass,
+		write:       pass,
+		createQuota: pass,
+		idempotency: middleware.Idempotency(idempotencyService, logger),
+	})
+	router.GET("/panic", func(c *gin.Context) { panic("handler failed") })
+
+	if w := serve(router, http.MethodPost, "/strings", `{"value": "scraped"}`, http.Header{"Idempotency-Key": {"scrape"}}); w.Code != http.StatusCreated {
+		t.Fatalf("create: got %d, want 201: %s", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodGet, "/panic", "", nil); w.Code != http.StatusInternalServerError {
+		t.Fatalf("panic: got %d, want 500", w.Code)
+	}
+
+	scrape := serve(router, http.MethodGet, "/metrics", "", nil)
+	if scrape.Code != http.StatusOK {
+		t.Fatalf("scrape: got %d, want 200", scrape.Code)
+	}
+	body, _ := io.ReadAll(scrape.Body)
+	for _, want := range []string{
+		`string_analyzer_http_requests_total{method="POST",route="/strings",status="201"} 1`,
+		`string_analyzer_http_requests_total{method="GET",route="/panic",status="500"} 1`,
+		`string_analyzer_repository_query_duration_seconds_count{method="CreateNewStringRecord",outcome="ok",repository="strings"} 1`,
+		`string_analyzer_repository_query_duration_seconds_count{method="Reserve",outcome="ok",repository="idempotency_keys"} 1`,
+		`string_analyzer_repository_query_duration_seconds_count{method="Complete",outcome="ok",repository="idempotency_keys"} 1`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("scrape is missing %s", want)
+		}
+	}
+}
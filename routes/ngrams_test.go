@@ -0,0 +1,120 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"testing"
+
+	"task_one/dto"
+
+	"github.com/gin-gonic/gin"
+)
+
+func createStrings(t *testing.T, router *gin.Engine, values ...string) {
+	t.Helper()
+	for _, value := range values {
+		body, _ := json.Marshal(map[string]string{"value": value})
+		if w := serve(router, http.MethodPost, "/strings", string(body), nil); w.Code != http.StatusCreated {
+			t.Fatalf("create %q: %d %s", value, w.Code, w.Body)
+		}
+	}
+}
+
+// Only bigrams are counted, as TestMain registers them, so contains_ngram
+// takes two characters
+func TestContainsNGramFilter(t *testing.T) {
+	router := newTestRouter(t)
+	createStrings(t, router, "Abab", "Äöü", "日本語", "xyz")
+
+	tests := []struct {
+		ngram  string
+		status int
+		want   []string
+	}{
+		{"ab", http.StatusOK, []string{"Abab"}},
+		// Matched lowercased, like the stored n-grams
+		{"BA", http.StatusOK, []string{"Abab"}},
+		// Length is counted in characters: these are bigrams of 4 and 6 bytes
+		{"ÄÖ", http.StatusOK, []string{"Äöü"}},
+		{"本語", http.StatusOK, []string{"日本語"}},
+		{"zz", http.StatusOK, nil},
+		// Sizes that are not counted
+		{"a", http.StatusBadRequest, nil},
+		{"aba", http.StatusBadRequest, nil},
+		{"日本語", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		w := serve(router, http.MethodGet, "/strings?contains_ngram="+url.QueryEscape(tt.ngram), "", nil)
+		if w.Code != tt.status {
+			t.Errorf("contains_ngram=%s: status %d, want %d: %s", tt.ngram, w.Code, tt.status, w.Body)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var response dto.FilterByCriteriaResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, entry := range response.Data {
+			got = append(got, entry.Value)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("contains_ngram=%s: found %q, want %q", tt.ngram, got, tt.want)
+		}
+		if response.FiltersApplied["contains_ngram"] != tt.ngram {
+			t.Errorf("contains_ngram=%s: filters applied %v", tt.ngram, response.FiltersApplied)
+		}
+	}
+}
+
+func TestStats(t *testing.T) {
+	router := newTestRouter(t)
+	createStrings(t, router, "abab", "abc", "Bc")
+
+	tests := []struct {
+		query  string
+		status int
+		want   []dto.NGramCount
+	}{
+		{"", http.StatusOK, []dto.NGramCount{
+			{NGram: "ab", Occurrences: 3, Strings: 2},
+			{NGram: "bc", Occurrences: 2, Strings: 2},
+			{NGram: "ba", Occurrences: 1, Strings: 1},
+		}},
+		{"?top=1", http.StatusOK, []dto.NGramCount{{NGram: "ab", Occurrences: 3, Strings: 2}}},
+		{"?top=0", http.StatusBadRequest, nil},
+		{"?top=101", http.StatusBadRequest, nil},
+		{"?top=many", http.StatusBadRequest, nil},
+	}
+	for _, tt := range tests {
+		w := serve(router, http.MethodGet, "/strings/stats"+tt.query, "", nil)
+		if w.Code != tt.status {
+			t.Errorf("%q: status %d, want %d: %s", tt.query, w.Code, tt.status, w.Body)
+			continue
+		}
+		if tt.status != http.StatusOK {
+			continue
+		}
+		var response dto.StringStatsResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+			t.Fatal(err)
+		}
+		if response.Count != 3 || !slices.Equal(response.TopNGrams["2"], tt.want) || len(response.TopNGrams) != 1 {
+			t.Errorf("%q: stats %+v, want 3 strings and bigrams %+v", tt.query, response, tt.want)
+		}
+	}
+
+	// The collection version validates the stats
+	w := serve(router, http.MethodGet, "/strings/stats", "", nil)
+	etag := w.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("stats sent without an ETag")
+	}
+	if w := serve(router, http.MethodGet, "/strings/stats", "", http.Header{"If-None-Match": {etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("status %d with a current ETag, want 304", w.Code)
+	}
+}
@@ -0,0 +1,117 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"task_one/dto"
+	"task_one/handlers"
+	"task_one/migrations"
+	"task_one/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// fakeHealthRepository answers the database checks with fixed results
+type fakeHealthRepository struct {
+	pingErr       error
+	schemaVersion int
+}
+
+func (r *fakeHealthRepository) Ping(ctx context.Context) error {
+	return r.pingErr
+}
+
+func (r *fakeHealthRepository) SchemaVersion(ctx context.Context) (int, error) {
+	return r.schemaVersion, r.pingErr
+}
+
+// brokenParser matches nothing, like a parser whose rules failed to load
+type brokenParser struct {
+	services.NaturalLanguageParser
+}
+
+func (brokenParser) CheckRules() error {
+	return errors.New("no rules loaded")
+}
+
+func newProbeRouter(healthService services.HealthService) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	registerProbeRoutes(router, handlers.NewHealthHandler(healthService), prometheus.NewRegistry())
+	return router
+}
+
+func readiness(t *testing.T, router *gin.Engine) (int, dto.ReadinessResponse) {
+	t.Helper()
+	w := serve(router, http.MethodGet, "/readyz", "", nil)
+	var response dto.ReadinessResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatalf("readiness body %s: %v", w.Body, err)
+	}
+	return w.Code, response
+}
+
+func TestReadiness(t *testing.T) {
+	parser := services.NewNaturalLanguageParser(nil)
+	tests := []struct {
+		name   string
+		repo   *fakeHealthRepository
+		parser services.NaturalLanguageParser
+		failed string
+	}{
+		{"ready", &fakeHealthRepository{schemaVersion: migrations.Latest()}, parser, ""},
+		{"database down", &fakeHealthRepository{pingErr: errors.New("connection refused")}, parser, "database"},
+		{"pending migrations", &fakeHealthRepository{schemaVersion: migrations.Latest() - 1}, parser, "migrations"},
+		{"parser rules", &fakeHealthRepository{schemaVersion: migrations.Latest()}, brokenParser{}, "parser"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			router := newProbeRouter(services.NewHealthService(tc.repo, tc.parser))
+
+			if w := serve(router, http.MethodGet, "/healthz", "", nil); w.Code != http.StatusOK {
+				t.Fatalf("liveness: got %d, want 200 whatever readiness says", w.Code)
+			}
+
+			status, response := readiness(t, router)
+			if tc.failed == "" {
+				if status != http.StatusOK || response.Status != "ready" {
+					t.Fatalf("got %d %+v, want 200 ready", status, response)
+				}
+				for _, check := range []string{"server", "database", "migrations", "parser"} {
+					if response.Checks[check] != "ok" {
+						t.Fatalf("check %s is %q, want ok", check, response.Checks[check])
+					}
+				}
+				return
+			}
+			if status != http.StatusServiceUnavailable || response.Status != "not ready" {
+				t.Fatalf("got %d %+v, want 503 not ready", status, response)
+			}
+			if response.Checks[tc.failed] == "ok" {
+				t.Fatalf("check %s passed: %+v", tc.failed, response.Checks)
+			}
+		})
+	}
+}
+
+func TestReadinessFailsWhileDraining(t *testing.T) {
+	healthService := services.NewHealthService(&fakeHealthRepository{schemaVersion: migrations.Latest()}, services.NewNaturalLanguageParser(nil))
+	router := newProbeRouter(healthService)
+	if status, _ := readiness(t, router); status != http.StatusOK {
+		t.Fatalf("before draining: got %d, want 200", status)
+	}
+
+	healthService.SetDraining()
+	status, response := readiness(t, router)
+	if status != http.StatusServiceUnavailable || response.Checks["server"] != "draining" {
+		t.Fatalf("while draining: got %d %+v, want 503 with server draining", status, response)
+	}
+	if w := serve(router, http.MethodGet, "/healthz", "", nil); w.Code != http.StatusOK {
+		t.Fatalf("liveness while draining: got %d, want 200", w.Code)
+	}
+}
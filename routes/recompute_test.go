@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"testing"
+
+	"task_one/dto"
+	"task_one/handlers"
+	"task_one/middleware"
+	"task_one/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// blockingStringService recomputes until it is cancelled
+type blockingStringService struct {
+	services.StringService
+}
+
+func (blockingStringService) Recompute(ctx context.Context, options services.RecomputeOptions, report func(dto.RecomputeProgress)) (dto.RecomputeProgress, error) {
+	<-ctx.Done()
+	return dto.RecomputeProgress{}, ctx.Err()
+}
+
+func TestRecomputeRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.DiscardHandler)
+	job := services.NewRecomputeJob(blockingStringService{}, logger)
+	defer job.Stop()
+
+	router := gin.New()
+	api := router.Group("", middleware.Authenticate(nil, nil, false, logger))
+	registerRecomputeRoutes(api, handlers.NewRecomputeHandler(job, services.RecomputeOptions{BatchSize: 100}, logger))
+
+	steps := []struct {
+		method string
+		want   int
+	}{
+		{http.MethodDelete, http.StatusNotFound},
+		{http.MethodPost, http.StatusAccepted},
+		{http.MethodPost, http.StatusConflict},
+		{http.MethodDelete, http.StatusOK},
+		{http.MethodDelete, http.StatusNotFound},
+		{http.MethodPost, http.StatusAccepted},
+	}
+	for i, step := range steps {
+		if w := serve(router, step.method, "/admin/recompute", "", nil); w.Code != step.want {
+			t.Fatalf("step %d: %s status %d, want %d: %s", i, step.method, w.Code, step.want, w.Body)
+		}
+	}
+}
@@ -1,22 +1,184 @@
 package routes
 
 import (
+	"log/slog"
+	"net/http"
+	"task_one/auth"
+	"task_one/config"
 	"task_one/handlers"
+	"task_one/initializers"
+	"task_one/metrics"
+	"task_one/middleware"
 	"task_one/repository"
 	"task_one/services"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"gorm.io/gorm"
 )
 
-func SetupRoutes(router *gin.Engine, db *gorm.DB) {
-	stringRepo := repository.NewStringRepository(db)
-	stringService := services.NewStringService(stringRepo)
-	stringHandler := handlers.NewStringsHandler(stringService)
+// SetupRoutes registers every route on router. The returned health service
+// lets the caller fail readiness while the server drains, and the recompute
+// job must be stopped before the database is closed.
+func SetupRoutes(router *gin.Engine, db *gorm.DB, cfg *config.Config, logger *slog.Logger) (services.HealthService, *services.RecomputeJob, error) {
+	jwtVerifier, err := initializers.NewJWTVerifier(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	registry, m, err := initializers.NewMetrics(db)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	parser := services.NewNaturalLanguageParser(m)
+
+	stringCache, err := initializers.NewCache(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The cache sits outside the instrumentation so query metrics only count
+	// calls that reach the database
+	stringRepo := repository.NewInstrumentedStringRepository(repository.NewStringRepository(db, logger), m)
+	if stringCache != nil {
+		stringRepo = repository.NewCachedStringRepository(stringRepo, stringCache, cfg.CacheTTL, m, logger)
+	}
+	stringService := services.NewStringService(stringRepo, parser, initializers.NewValuePolicy(cfg), m, logger)
+
+	recomputeJob := services.NewRecomputeJob(stringService, logger)
+	recomputeHandler := handlers.NewRecomputeHandler(recomputeJob, services.RecomputeOptions{
+		BatchSize: cfg.RecomputeBatchSize,
+		Pause:     cfg.RecomputePause,
+	}, logger)
+
+	collectionRepo := repository.NewInstrumentedCollectionRepository(repository.NewCollectionRepository(db, logger), m)
+	collectionService := services.NewCollectionService(collectionRepo)
+	stringHandler := handlers.NewStringsHandler(stringService, collectionService, logger)
+	collectionHandler := handlers.NewCollectionsHandler(collectionService, logger)
+
+	apiKeyRepo := repository.NewInstrumentedAPIKeyRepository(repository.NewAPIKeyRepository(db, logger), m)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, logger)
+	apiKeyHandler := handlers.NewAPIKeysHandler(apiKeyService, logger)
+
+	healthRepo := repository.NewHealthRepository(db, logger)
+	healthService := services.NewHealthService(healthRepo, parser)
+	healthHandler := handlers.NewHealthHandler(healthService)
+
+	quotaRepo := repository.NewQuotaRepository(db, logger)
+	quotaService := services.NewQuotaService(quotaRepo, cfg.DailyCreateQuota, logger)
+
+	idempotencyRepo := repository.NewInstrumentedIdempotencyRepository(repository.NewIdempotencyRepository(db, logger), m)
+	idempotencyService := services.NewIdempotencyService(idempotencyRepo, cfg.IdempotencyKeyTTL, cfg.IdempotencyLease, logger)
+
+	limits := stringRouteLimits{
+		read:            middleware.RateLimit(middleware.NewRateLimiter(cfg.RateLimitReadRPS, cfg.RateLimitReadBurst)),
+		write:           middleware.RateLimit(middleware.NewRateLimiter(cfg.RateLimitWriteRPS, cfg.RateLimitWriteBurst)),
+		naturalLanguage: middleware.RateLimit(middleware.NewRateLimiter(cfg.RateLimitNLRPS, cfg.RateLimitNLBurst)),
+		createQuota:     middleware.DailyCreationQuota(quotaService, logger),
+		idempotency:     middleware.Idempotency(idempotencyService, logger),
+	}
+
+	read := middleware.RequireScope(auth.ScopeStringsRead)
+	admin := middleware.RequireScope(auth.ScopeAdmin)
+
+	registerObservability(router, m, logger)
+
+	registerProbeRoutes(router, healthHandler, registry)
+
 	// Routes
-	router.POST("/strings", stringHandler.CreateNewString)
-	router.GET("/strings/:string_value", stringHandler.GetStringByValue)
-	router.GET("/strings", stringHandler.FilterByCriteria)
-	router.GET("/strings/filter-by-natural-language", stringHandler.FilterByNaturalLanguage)
-	router.DELETE("/strings/:string_value", stringHandler.DeleteStringEntry)
+	api := router.Group("",
+		middleware.QueryTimeout(cfg.QueryTimeout),
+		middleware.MaxBodySize(cfg.MaxRequestBodyBytes),
+		middleware.Authenticate(apiKeyService, jwtVerifier, cfg.AuthEnabled, logger),
+	)
+
+	api.POST("/admin/api-keys", admin, apiKeyHandler.CreateAPIKey)
+	api.GET("/admin/api-keys", admin, apiKeyHandler.ListAPIKeys)
+	api.DELETE("/admin/api-keys/:id", admin, apiKeyHandler.RevokeAPIKey)
+	api.GET("/admin/explain", admin, stringHandler.ExplainFilter)
+	registerRecomputeRoutes(api, recomputeHandler)
+
+	api.POST("/collections", admin, collectionHandler.CreateCollection)
+	api.GET("/collections", read, collectionHandler.ListCollections)
+	api.GET("/collections/:name", read, collectionHandler.GetCollection)
+	api.DELETE("/collections/:name", admin, collectionHandler.DeleteCollection)
+
+	// The unscoped routes operate on the default collection
+	registerStringRoutes(api, stringHandler, limits)
+	registerStringRoutes(api.Group("/collections/:name", collectionHandler.RequireCollection), stringHandler, limits)
+	return healthService, recomputeJob, nil
+}
+
+// registerObservability installs the middleware every request passes
+// through. The request span and ID come first so every later log line can
+// carry them. Metrics sits outside Recovery so a panic is counted as the 500
+// Recovery answers with.
+func registerObservability(router gin.IRoutes, m *metrics.Metrics, logger *slog.Logger) {
+	router.Use(
+		otelgin.Middleware(initializers.TracingServiceName, otelgin.WithFilter(tracedRequest)),
+		middleware.RequestID(),
+		middleware.AccessLog(logger),
+		middleware.Metrics(m),
+		middleware.Recovery(logger),
+	)
+}
+
+// registerProbeRoutes registers probes and metrics. They are public so
+// orchestrators need no credentials.
+func registerProbeRoutes(router gin.IRoutes, healthHandler *handlers.HealthHandler, registry prometheus.Gatherer) {
+	router.GET("/metrics", gin.WrapH(promhttp.HandlerFor(registry, promhttp.HandlerOpts{})))
+	router.GET("/healthz", healthHandler.Liveness)
+	router.GET("/readyz", healthHandler.Readiness)
+	router.GET("/version", healthHandler.Version)
+}
+
+func registerRecomputeRoutes(group gin.IRoutes, recomputeHandler *handlers.RecomputeHandler) {
+	admin := middleware.RequireScope(auth.ScopeAdmin)
+
+	group.POST("/admin/recompute", admin, recomputeHandler.StartRecompute)
+	group.GET("/admin/recompute", admin, recomputeHandler.GetRecompute)
+	group.DELETE("/admin/recompute", admin, recomputeHandler.CancelRecompute)
+}
+
+// tracedRequest leaves scrapes and probes out of traces
+func tracedRequest(r *http.Request) bool {
+	switch r.URL.Path {
+	case "/metrics", "/healthz", "/readyz":
+		return false
+	}
+	return true
+}
+
+// stringRouteLimits holds the rate limiters shared by every collection, so a
+// client's budget does not grow with the number of collections it touches.
+// Idempotency sits with them because replays must not spend the quota.
+type stringRouteLimits struct {
+	read            gin.HandlerFunc
+	write           gin.HandlerFunc
+	naturalLanguage gin.HandlerFunc
+	createQuota     gin.HandlerFunc
+	idempotency     gin.HandlerFunc
+}
+
+func registerStringRoutes(group *gin.RouterGroup, stringHandler *handlers.StringsHandler, limits stringRouteLimits) {
+	read := middleware.RequireScope(auth.ScopeStringsRead)
+	write := middleware.RequireScope(auth.ScopeStringsWrite)
+	remove := middleware.RequireScope(auth.ScopeStringsDelete)
+
+	group.POST("/strings", write, limits.write, limits.idempotency, limits.createQuota, stringHandler.CreateNewString)
+	group.PUT("/strings", write, limits.write, limits.idempotency, limits.createQuota, stringHandler.PutString)
+	group.POST("/strings/lookup", read, limits.read, stringHandler.LookupString)
+	group.GET("/strings/trash", read, limits.read, stringHandler.ListTrash)
+	group.GET("/strings/stats", read, limits.read, stringHandler.Stats)
+	group.GET("/strings/id/:id", read, limits.read, stringHandler.GetStringById)
+	group.POST("/strings/id/:id/restore", remove, limits.write, stringHandler.RestoreStringEntry)
+	group.PATCH("/strings/id/:id", write, limits.write, stringHandler.UpdateStringEntry)
+	group.DELETE("/strings/id/:id", remove, limits.write, stringHandler.DeleteStringEntryById)
+	group.GET("/strings/:string_value", read, limits.read, stringHandler.GetStringByValue)
+	group.GET("/strings", read, limits.read, stringHandler.FilterByCriteria)
+	group.GET("/strings/filter-by-natural-language", read, limits.naturalLanguage, stringHandler.FilterByNaturalLanguage)
+	group.DELETE("/strings/:string_value", remove, limits.write, stringHandler.DeleteStringEntry)
 }
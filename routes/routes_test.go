@@ -0,0 +1,608 @@
+package routes
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"task_one/analysis"
+	"task_one/dto"
+	"task_one/handlers"
+	"task_one/metrics"
+	"task_one/middleware"
+	"task_one/models"
+	"task_one/repository"
+	"task_one/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"gorm.io/datatypes"
+)
+
+// fakeStringRepository keeps live entries in memory. Inserts are serialized
+// so concurrent requests race the way they do on the primary key.
+type fakeStringRepository struct {
+	repository.StringRepository
+
+	mu      sync.Mutex
+	entries map[string]models.StringEntry
+}
+
+func newFakeStringRepository() *fakeStringRepository {
+	return &fakeStringRepository{entries: map[string]models.StringEntry{}}
+}
+
+func (r *fakeStringRepository) CreateNewStringRecord(ctx context.Context, stringData models.StringEntry) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := stringData.Collection + "/" + stringData.ID
+	if _, ok := r.entries[key]; ok {
+		return false, nil
+	}
+	r.entries[key] = stringData
+	return true, nil
+}
+
+func (r *fakeStringRepository) GetStringByHash(ctx context.Context, collection, hash string) (*models.StringEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[collection+"/"+hash]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+func (r *fakeStringRepository) GetStringById(ctx context.Context, collection, id string) (*models.StringEntry, error) {
+	return r.GetStringByHash(ctx, collection, id)
+}
+
+// UpdateStringRecord and DeleteStringValue hold the lock across the
+// precondition and the write, as the row lock does in the database
+
+func (r *fakeStringRepository) UpdateStringRecord(ctx context.Context, collection, hash string, updates map[string]any, precondition repository.Precondition) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[collection+"/"+hash]
+	if !ok {
+		return false, nil
+	}
+	if precondition != nil && !precondition(entry) {
+		return false, repository.ErrPreconditionFailed
+	}
+	if tags, ok := updates["tags"].(datatypes.JSON); ok {
+		entry.Tags = tags
+	}
+	if metadata, ok := updates["metadata"].(datatypes.JSON); ok {
+		entry.Metadata = metadata
+	}
+	r.entries[collection+"/"+hash] = entry
+	return true, nil
+}
+
+func (r *fakeStringRepository) DeleteStringValue(ctx context.Context, collection, hash string, precondition repository.Precondition) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	entry, ok := r.entries[collection+"/"+hash]
+	if !ok {
+		return false, nil
+	}
+	if precondition != nil && !precondition(entry) {
+		return false, repository.ErrPreconditionFailed
+	}
+	delete(r.entries, collection+"/"+hash)
+	return true, nil
+}
+
+// FilterByCriteria applies the entropy range and contains_ngram, the only
+// filters the tests use
+func (r *fakeStringRepository) FilterByCriteria(ctx context.Context, collection string, input dto.FilterByCriteriaData) (*[]models.StringEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	bounds := input.Ranges["entropy"]
+	found := []models.StringEntry{}
+	for _, entry := range r.entries {
+		if entry.Collection != collection || entry.Entropy == nil {
+			continue
+		}
+		if (bounds.Min != nil && *entry.Entropy < *bounds.Min) || (bounds.Max != nil && *entry.Entropy > *bounds.Max) {
+			continue
+		}
+		if input.ContainsNGram != nil {
+			ngram := strings.ToLower(*input.ContainsNGram)
+			if _, ok := ngramsOf(entry, utf8.RuneCountInString(ngram))[ngram]; !ok {
+				continue
+			}
+		}
+		found = append(found, entry)
+	}
+	return &found, nil
+}
+
+// Stats sums the n-gram maps the way the SQL does: by occurrences, then by
+// n-gram, over live entries
+func (r *fakeStringRepository) Stats(ctx context.Context, collection string, ngramSizes []int, top int) (*repository.StringStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := repository.StringStats{TopNGrams: map[int][]repository.NGramCount{}}
+	for _, n := range ngramSizes {
+		counts := map[string]*repository.NGramCount{}
+		for _, entry := range r.entries {
+			if entry.Collection != collection {
+				continue
+			}
+			for ngram, occurrences := range ngramsOf(entry, n) {
+				if counts[ngram] == nil {
+					counts[ngram] = &repository.NGramCount{NGram: ngram}
+				}
+				counts[ngram].Occurrences += int64(occurrences)
+				counts[ngram].Strings++
+			}
+		}
+		ranked := []repository.NGramCount{}
+		for _, count := range counts {
+			ranked = append(ranked, *count)
+		}
+		slices.SortFunc(ranked, func(a, b repository.NGramCount) int {
+			return cmp.Or(cmp.Compare(b.Occurrences, a.Occurrences), cmp.Compare(a.NGram, b.NGram))
+		})
+		stats.TopNGrams[n] = ranked[:min(top, len(ranked))]
+	}
+	for _, entry := range r.entries {
+		if entry.Collection == collection {
+			stats.Count++
+		}
+	}
+	return &stats, nil
+}
+
+// ngramsOf decodes the n-grams of size n stored on entry
+func ngramsOf(entry models.StringEntry, n int) map[string]int {
+	var properties map[string]json.RawMessage
+	var ngrams map[string]int
+	if json.Unmarshal(entry.Properties, &properties) == nil {
+		json.Unmarshal(properties[analysis.NGramPropertyName(n)], &ngrams)
+	}
+	return ngrams
+}
+
+// fakeCollectionRepository holds only the default collection
+type fakeCollectionRepository struct {
+	repository.CollectionRepository
+}
+
+func (fakeCollectionRepository) GetCollection(ctx context.Context, name string) (*models.Collection, error) {
+	if name != services.DefaultCollection {
+		return nil, nil
+	}
+	return &models.Collection{Name: name, Version: 1}, nil
+}
+
+// fakeIdempotencyRepository follows the reservation rules of the SQL in
+// idempotencyRepository
+type fakeIdempotencyRepository struct {
+	mu      sync.Mutex
+	records map[string]models.IdempotencyKey
+}
+
+func newFakeIdempotencyRepository() *fakeIdempotencyRepository {
+	return &fakeIdempotencyRepository{records: map[string]models.IdempotencyKey{}}
+}
+
+func (r *fakeIdempotencyRepository) Reserve(ctx context.Context, clientKey, key, fingerprint string, expiredBefore, abandonedBefore time.Time) (time.Time, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if record, ok := r.records[clientKey+"/"+key]; ok {
+		expired := record.CreatedAt.Before(expiredBefore)
+		abandoned := record.StatusCode == 0 && record.CreatedAt.Before(abandonedBefore)
+		if !expired && !abandoned {
+			return time.Time{}, false, nil
+		}
+	}
+	reservedAt := time.Now().UTC()
+	r.records[clientKey+"/"+key] = models.IdempotencyKey{
+		ClientKey:   clientKey,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   reservedAt,
+	}
+	return reservedAt, true, nil
+}
+
+func (r *fakeIdempotencyRepository) Get(ctx context.Context, clientKey, key string) (*models.IdempotencyKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[clientKey+"/"+key]
+	if !ok {
+		return nil, nil
+	}
+	return &record, nil
+}
+
+func (r *fakeIdempotencyRepository) Complete(ctx context.Context, clientKey, key string, reservedAt time.Time, statusCode int, headers datatypes.JSON, body []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	record, ok := r.records[clientKey+"/"+key]
+	if !ok || !record.CreatedAt.Equal(reservedAt) || record.StatusCode != 0 {
+		return nil
+	}
+	record.StatusCode, record.Headers, record.Body = statusCode, headers, body
+	r.records[clientKey+"/"+key] = record
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) Release(ctx context.Context, clientKey, key string, reservedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if record, ok := r.records[clientKey+"/"+key]; ok && record.CreatedAt.Equal(reservedAt) && record.StatusCode == 0 {
+		delete(r.records, clientKey+"/"+key)
+	}
+	return nil
+}
+
+func (r *fakeIdempotencyRepository) PurgeBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	return 0, nil
+}
+
+func pass(c *gin.Context) { c.Next() }
+
+// newTestRouter serves the string routes of the default collection without
+// authentication, rate limits or quota
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.DiscardHandler)
+
+	stringService := services.NewStringService(newFakeStringRepository(), nil, services.ValuePolicy{}, metrics.New(prometheus.NewRegistry()), logger)
+	idempotencyService := services.NewIdempotencyService(newFakeIdempotencyRepository(), time.Hour, time.Minute, logger)
+
+	router := gin.New()
+	router.Use(middleware.Recovery(logger))
+	api := router.Group("", middleware.Authenticate(nil, nil, false, logger))
+	collectionService := services.NewCollectionService(fakeCollectionRepository{})
+	registerStringRoutes(api, handlers.NewStringsHandler(stringService, collectionService, logger), stringRouteLimits{
+		read:        pass,
+		write:       pass,
+		createQuota: pass,
+		idempotency: middleware.Idempotency(idempotencyService, logger),
+	})
+
+	panicked := false
+	api.POST("/panic", middleware.Idempotency(idempotencyService, logger), func(c *gin.Context) {
+		if !panicked {
+			panicked = true
+			panic("handler failed")
+		}
+		c.JSON(http.StatusCreated, gin.H{"message": "created"})
+	})
+	return router
+}
+
+func serve(router *gin.Engine, method, path, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestConcurrentCreateConflicts(t *testing.T) {
+	router := newTestRouter(t)
+
+	const requests = 20
+	statuses := make(chan int, requests)
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			statuses <- serve(router, http.MethodPost, "/strings", `{"value": "race condition"}`, nil).Code
+		}()
+	}
+	wg.Wait()
+	close(statuses)
+
+	counts := map[int]int{}
+	for status := range statuses {
+		counts[status]++
+	}
+	if counts[http.StatusCreated] != 1 || counts[http.StatusConflict] != requests-1 {
+		t.Fatalf("got statuses %v, want one 201 and %d 409", counts, requests-1)
+	}
+}
+
+func TestPutStringCreatesThenReturnsStored(t *testing.T) {
+	router := newTestRouter(t)
+
+	first := serve(router, http.MethodPut, "/strings", `{"value": "put me"}`, nil)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first PUT: got %d, want 201: %s", first.Code, first.Body)
+	}
+	second := serve(router, http.MethodPut, "/strings", `{"value": "put me"}`, nil)
+	if second.Code != http.StatusOK {
+		t.Fatalf("second PUT: got %d, want 200: %s", second.Code, second.Body)
+	}
+
+	var created, stored struct {
+		Id    string `json:"id"`
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(first.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(second.Body.Bytes(), &stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored != created {
+		t.Fatalf("second PUT returned %+v, want the stored %+v", stored, created)
+	}
+	if second.Header().Get("Location") != first.Header().Get("Location") {
+		t.Fatalf("Location changed from %q to %q", first.Header().Get("Location"), second.Header().Get("Location"))
+	}
+}
+
+func TestIdempotencyKeyReplaysStoredResponse(t *testing.T) {
+	router := newTestRouter(t)
+	header := http.Header{"Idempotency-Key": {"create-once"}}
+
+	first := serve(router, http.MethodPost, "/strings", `{"value": "replay me"}`, header)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first POST: got %d, want 201: %s", first.Code, first.Body)
+	}
+	if first.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatal("first POST was marked as replayed")
+	}
+
+	replay := serve(router, http.MethodPost, "/strings", `{"value": "replay me"}`, header)
+	if replay.Code != http.StatusCreated {
+		t.Fatalf("replay: got %d, want the stored 201: %s", replay.Code, replay.Body)
+	}
+	if replay.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatal("replay is missing Idempotent-Replayed: true")
+	}
+	if replay.Body.String() != first.Body.String() {
+		t.Fatalf("replay body %s, want the stored %s", replay.Body, first.Body)
+	}
+	for _, name := range []string{"Content-Type", "Location"} {
+		if replay.Header().Get(name) != first.Header().Get(name) {
+			t.Fatalf("replayed %s %q, want %q", name, replay.Header().Get(name), first.Header().Get(name))
+		}
+	}
+
+	// The same key on another request is refused rather than replayed
+	other := serve(router, http.MethodPost, "/strings?include=ngrams", `{"value": "replay me"}`, header)
+	if other.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("reused key: got %d, want 422: %s", other.Code, other.Body)
+	}
+}
+
+func TestIdempotencyKeyReleasedAfterPanic(t *testing.T) {
+	router := newTestRouter(t)
+	header := http.Header{"Idempotency-Key": {"panics-once"}}
+
+	first := serve(router, http.MethodPost, "/panic", `{}`, header)
+	if first.Code != http.StatusInternalServerError {
+		t.Fatalf("panicking request: got %d, want 500: %s", first.Code, first.Body)
+	}
+	retry := serve(router, http.MethodPost, "/panic", `{}`, header)
+	if retry.Code != http.StatusCreated {
+		t.Fatalf("retry: got %d, want 201: %s", retry.Code, retry.Body)
+	}
+}
+
+func TestIdempotencyTakeoverKeepsRetryOutcome(t *testing.T) {
+	// The original request outlives its lease and a retry takes the key
+	// over. Whatever the original answers while the retry is still running
+	// must leave the retry's reservation, and so its response, in place.
+	for _, originalStatus := range []int{http.StatusCreated, http.StatusInternalServerError} {
+		t.Run(http.StatusText(originalStatus), func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			logger := slog.New(slog.DiscardHandler)
+			idempotencyService := services.NewIdempotencyService(newFakeIdempotencyRepository(), time.Hour, time.Nanosecond, logger)
+
+			entered := []chan struct{}{make(chan struct{}), make(chan struct{})}
+			release := []chan struct{}{make(chan struct{}), make(chan struct{})}
+			var calls atomic.Int32
+			router := gin.New()
+			router.POST("/slow", middleware.Idempotency(idempotencyService, logger), func(c *gin.Context) {
+				switch calls.Add(1) {
+				case 1:
+					close(entered[0])
+					<-release[0]
+					c.JSON(originalStatus, gin.H{"message": "original"})
+				case 2:
+					close(entered[1])
+					<-release[1]
+					c.JSON(http.StatusCreated, gin.H{"message": "retry"})
+				default:
+					c.JSON(http.StatusCreated, gin.H{"message": "ran again"})
+				}
+			})
+			header := http.Header{"Idempotency-Key": {"slow"}}
+
+			var wg sync.WaitGroup
+			send := func() {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					serve(router, http.MethodPost, "/slow", `{}`, header)
+				}()
+			}
+			send()
+			<-entered[0]
+			time.Sleep(time.Millisecond)
+			send()
+			<-entered[1]
+
+			// The original finishes first, then the retry
+			close(release[0])
+			time.Sleep(10 * time.Millisecond)
+			close(release[1])
+			wg.Wait()
+
+			replay := serve(router, http.MethodPost, "/slow", `{}`, header)
+			if replay.Header().Get("Idempotent-Replayed") != "true" || !strings.Contains(replay.Body.String(), `"retry"`) {
+				t.Fatalf("replay: got %d %s, want the retry's stored response", replay.Code, replay.Body)
+			}
+		})
+	}
+}
+
+func TestIfMatchMakesWritesConditional(t *testing.T) {
+	router := newTestRouter(t)
+
+	created := serve(router, http.MethodPost, "/strings", `{"value": "guarded"}`, nil)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create: got %d, want 201: %s", created.Code, created.Body)
+	}
+	location := created.Header().Get("Location")
+	read := serve(router, http.MethodGet, location, "", nil)
+	etag := read.Header().Get("ETag")
+	if read.Code != http.StatusOK || etag == "" {
+		t.Fatalf("read: got %d with ETag %q", read.Code, etag)
+	}
+
+	// The first writer holding the ETag wins; the second sees the change
+	first := serve(router, http.MethodPatch, location, `{"tags": ["first"]}`, http.Header{"If-Match": {etag}})
+	if first.Code != http.StatusOK {
+		t.Fatalf("first PATCH: got %d, want 200: %s", first.Code, first.Body)
+	}
+	second := serve(router, http.MethodPatch, location, `{"tags": ["second"]}`, http.Header{"If-Match": {etag}})
+	if second.Code != http.StatusPreconditionFailed {
+		t.Fatalf("second PATCH: got %d, want 412: %s", second.Code, second.Body)
+	}
+
+	stale := serve(router, http.MethodDelete, location, "", http.Header{"If-Match": {etag}})
+	if stale.Code != http.StatusPreconditionFailed {
+		t.Fatalf("DELETE with a stale ETag: got %d, want 412: %s", stale.Code, stale.Body)
+	}
+	current := serve(router, http.MethodDelete, location, "", http.Header{"If-Match": {first.Header().Get("ETag")}})
+	if current.Code != http.StatusNoContent {
+		t.Fatalf("DELETE with the current ETag: got %d, want 204: %s", current.Code, current.Body)
+	}
+	gone := serve(router, http.MethodDelete, location, "", http.Header{"If-Match": {"*"}})
+	if gone.Code != http.StatusPreconditionFailed {
+		t.Fatalf("DELETE of a missing string with If-Match: *: got %d, want 412: %s", gone.Code, gone.Body)
+	}
+}
+
+func TestControlCharactersRejectedInTagsAndMetadata(t *testing.T) {
+	router := newTestRouter(t)
+
+	created := serve(router, http.MethodPost, "/strings", `{"value": "tagged"}`, nil)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create: got %d, want 201: %s", created.Code, created.Body)
+	}
+	location := created.Header().Get("Location")
+
+	bodies := []string{
+		`{"tags": ["a\u0000b"]}`,
+		`{"metadata": {"note": "a\u0000b"}}`,
+		`{"metadata": {"a\u0000b": "note"}}`,
+		`{"metadata": {"nested": [{"note": "a\u0007b"}]}}`,
+	}
+	for _, body := range bodies {
+		if w := serve(router, http.MethodPatch, location, body, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("PATCH %s: got %d, want 400: %s", body, w.Code, w.Body)
+		}
+		create := `{"value": "other", ` + strings.TrimPrefix(body, "{")
+		if w := serve(router, http.MethodPost, "/strings", create, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("POST %s: got %d, want 400: %s", create, w.Code, w.Body)
+		}
+	}
+}
+
+func TestETagMatchesSentRepresentation(t *testing.T) {
+	router := newTestRouter(t)
+
+	created := serve(router, http.MethodPost, "/strings", `{"value": "etag me"}`, nil)
+	if created.Code != http.StatusCreated {
+		t.Fatalf("create: got %d, want 201: %s", created.Code, created.Body)
+	}
+	location := created.Header().Get("Location")
+
+	plain := serve(router, http.MethodGet, location, "", nil)
+	withNGrams := serve(router, http.MethodGet, location+"?include=ngrams", "", nil)
+	if !strings.Contains(withNGrams.Body.String(), "ngram_frequency_map_2") || strings.Contains(plain.Body.String(), "ngram_frequency_map_2") {
+		t.Fatalf("include=ngrams did not select the n-gram maps:\n%s\n%s", plain.Body, withNGrams.Body)
+	}
+
+	// Each ETag is that of the body it came with
+	for _, w := range []*httptest.ResponseRecorder{plain, withNGrams} {
+		var body bytes.Buffer
+		if err := json.Compact(&body, w.Body.Bytes()); err != nil {
+			t.Fatal(err)
+		}
+		sum := sha256.Sum256(body.Bytes())
+		if want := `"` + hex.EncodeToString(sum[:16]) + `"`; w.Header().Get("ETag") != want {
+			t.Fatalf("ETag %s, want the hash of the body %s", w.Header().Get("ETag"), want)
+		}
+	}
+	if plain.Header().Get("ETag") == withNGrams.Header().Get("ETag") {
+		t.Fatal("different bodies share an ETag")
+	}
+
+	// If-None-Match only matches the representation asked for
+	if w := serve(router, http.MethodGet, location+"?include=ngrams", "", http.Header{"If-None-Match": {plain.Header().Get("ETag")}}); w.Code != http.StatusOK {
+		t.Fatalf("If-None-Match with the other representation's ETag: got %d, want 200", w.Code)
+	}
+
+	// If-Match accepts the ETag of any current representation
+	patched := serve(router, http.MethodPatch, location, `{"tags": ["a"]}`, http.Header{"If-Match": {withNGrams.Header().Get("ETag")}})
+	if patched.Code != http.StatusOK {
+		t.Fatalf("PATCH with the include=ngrams ETag: got %d, want 200: %s", patched.Code, patched.Body)
+	}
+	if w := serve(router, http.MethodPatch, location, `{"tags": ["b"]}`, http.Header{"If-Match": {patched.Header().Get("ETag")}}); w.Code != http.StatusOK {
+		t.Fatalf("PATCH with the plain ETag: got %d, want 200: %s", w.Code, w.Body)
+	}
+	if w := serve(router, http.MethodPatch, location, `{"tags": ["c"]}`, http.Header{"If-Match": {plain.Header().Get("ETag")}}); w.Code != http.StatusPreconditionFailed {
+		t.Fatalf("PATCH with a stale ETag: got %d, want 412: %s", w.Code, w.Body)
+	}
+}
+
+func TestEntropyRangeFilter(t *testing.T) {
+	router := newTestRouter(t)
+	createStrings(t, router, "aaaa", "aabb", "abcd")
+
+	w := serve(router, http.MethodGet, "/strings?min_entropy=0.5&max_entropy=1.5", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200: %s", w.Code, w.Body)
+	}
+	var response struct {
+		Data []struct {
+			Value string `json:"value"`
+		} `json:"data"`
+		FiltersApplied map[string]any `json:"filters_applied"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil {
+		t.Fatal(err)
+	}
+	if len(response.Data) != 1 || response.Data[0].Value != "aabb" {
+		t.Fatalf("data %+v, want only aabb", response.Data)
+	}
+	if response.FiltersApplied["min_entropy"] != 0.5 || response.FiltersApplied["max_entropy"] != 1.5 {
+		t.Fatalf("filters applied %v", response.FiltersApplied)
+	}
+
+	for _, query := range []string{"min_entropy=3&max_entropy=1", "min_entropy=high"} {
+		if w := serve(router, http.MethodGet, "/strings?"+query, "", nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", query, w.Code)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"task_one/auth"
+	"task_one/dto"
+	"task_one/models"
+	"task_one/repository"
+	"time"
+)
+
+// apiKeyPrefix marks plaintext keys so they are easy to spot in logs and configs
+const apiKeyPrefix = "sk_"
+
+// lastUsedGranularity bounds how often last_used_at is written for a key
+const lastUsedGranularity = time.Minute
+
+type APIKeyService interface {
+	CreateAPIKey(ctx context.Context, input dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, error)
+	ListAPIKeys(ctx context.Context) (*dto.ListAPIKeysResponse, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	Authenticate(ctx context.Context, rawKey string) (*auth.Principal, error)
+	EnsureBootstrapKey(ctx context.Context, rawKey string) error
+}
+
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	logger     *slog.Logger
+}
+
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, logger *slog.Logger) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		logger:     logger,
+	}
+}
+
+func (s *apiKeyService) CreateAPIKey(ctx context.Context, input dto.CreateAPIKeyRequest) (*dto.CreateAPIKeyResponse, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("invalid name: name is required")
+	}
+	scopes, err := normalizeScopes(input.Scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	rawKey, err := randomToken(32)
+	if err != nil {
+		return nil, err
+	}
+	rawKey = apiKeyPrefix + rawKey
+
+	created, err := s.storeKey(ctx, name, rawKey, scopes)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.CreateAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(*created, scopes),
+		Key:            rawKey,
+	}
+	return &response, nil
+}
+
+func (s *apiKeyService) ListAPIKeys(ctx context.Context) (*dto.ListAPIKeysResponse, error) {
+	keys, err := s.apiKeyRepo.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []dto.APIKeyResponse{}
+	for _, key := range *keys {
+		var scopes []string
+		if err := json.Unmarshal(key.Scopes, &scopes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal scopes: %v", err)
+		}
+		data = append(data, toAPIKeyResponse(key, scopes))
+	}
+
+	return &dto.ListAPIKeysResponse{
+		Data:  data,
+		Count: len(data),
+	}, nil
+}
+
+func (s *apiKeyService) RevokeAPIKey(ctx context.Context, id string) error {
+	revoked, err := s.apiKeyRepo.RevokeAPIKey(ctx, id, time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return fmt.Errorf("not found: api key does not exist or is already revoked")
+	}
+	return nil
+}
+
+func (s *apiKeyService) Authenticate(ctx context.Context, rawKey string) (*auth.Principal, error) {
+	key, err := s.apiKeyRepo.GetAPIKeyByHash(ctx, GetHash(rawKey))
+	if err != nil {
+		return nil, err
+	}
+	if key == nil || key.RevokedAt != nil {
+		return nil, fmt.Errorf("unauthorized: invalid api key")
+	}
+
+	var scopes []string
+	if err := json.Unmarshal(key.Scopes, &scopes); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal scopes: %v", err)
+	}
+
+	now := time.Now().UTC()
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > lastUsedGranularity {
+		if err := s.apiKeyRepo.TouchAPIKey(ctx, key.ID, now); err != nil {
+			s.logger.WarnContext(ctx, "Failed to record api key usage", slog.String("key_id", key.ID), slog.Any("error", err))
+		}
+	}
+
+	return &auth.Principal{
+		ID:     "apikey:" + key.ID,
+		Name:   key.Name,
+		Scopes: scopes,
+	}, nil
+}
+
+// EnsureBootstrapKey registers rawKey as an admin key unless it is already
+// known, so a fresh deployment can mint its first keys
+func (s *apiKeyService) EnsureBootstrapKey(ctx context.Context, rawKey string) error {
+	existing, err := s.apiKeyRepo.GetAPIKeyByHash(ctx, GetHash(rawKey))
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return nil
+	}
+
+	_, err = s.storeKey(ctx, "bootstrap", rawKey, []string{auth.ScopeAdmin})
+	return err
+}
+
+func (s *apiKeyService) storeKey(ctx context.Context, name, rawKey string, scopes []string) (*models.APIKey, error) {
+	id, err := randomToken(8)
+	if err != nil {
+		return nil, err
+	}
+	scopesJSON, _ := json.Marshal(scopes)
+
+	return s.apiKeyRepo.CreateAPIKey(ctx, models.APIKey{
+		ID:        id,
+		Name:      name,
+		Prefix:    rawKey[:min(len(rawKey), len(apiKeyPrefix)+6)],
+		KeyHash:   GetHash(rawKey),
+		Scopes:    scopesJSON,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// normalizeScopes rejects unknown scopes and drops duplicates
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("invalid scopes: at least one scope is required")
+	}
+	normalized := make([]string, 0, len(scopes))
+	seen := make(map[string]struct{})
+	for _, scope := range scopes {
+		if !auth.IsKnownScope(scope) {
+			return nil, fmt.Errorf("invalid scopes: unknown scope %q", scope)
+		}
+		if _, ok := seen[scope]; ok {
+			continue
+		}
+		seen[scope] = struct{}{}
+		normalized = append(normalized, scope)
+	}
+	return normalized, nil
+}
+
+// randomToken returns n random bytes, hex encoded
+func randomToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random token: %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func toAPIKeyResponse(key models.APIKey, scopes []string) dto.APIKeyResponse {
+	response := dto.APIKeyResponse{
+		Id:        key.ID,
+		Name:      key.Name,
+		Prefix:    key.Prefix,
+		Scopes:    scopes,
+		CreatedAt: key.CreatedAt.Format(time.RFC3339),
+	}
+	if key.LastUsedAt != nil {
+		response.LastUsedAt = key.LastUsedAt.Format(time.RFC3339)
+	}
+	if key.RevokedAt != nil {
+		response.RevokedAt = key.RevokedAt.Format(time.RFC3339)
+	}
+	return response
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"task_one/dto"
+	"task_one/models"
+	"task_one/repository"
+	"time"
+)
+
+// DefaultCollection holds every string created through the unscoped /strings routes
+const DefaultCollection = "default"
+
+var collectionNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+type CollectionService interface {
+	CreateCollection(ctx context.Context, input dto.CreateCollectionRequest) (*dto.CollectionResponse, error)
+	GetCollection(ctx context.Context, name string) (*dto.CollectionResponse, error)
+	ListCollections(ctx context.Context) (*dto.ListCollectionsResponse, error)
+	DeleteCollection(ctx context.Context, name string) error
+	CollectionVersion(ctx context.Context, name string) (int64, error)
+}
+
+type collectionService struct {
+	collectionRepo repository.CollectionRepository
+}
+
+func NewCollectionService(collectionRepo repository.CollectionRepository) CollectionService {
+	return &collectionService{
+		collectionRepo: collectionRepo,
+	}
+}
+
+func (s *collectionService) CreateCollection(ctx context.Context, input dto.CreateCollectionRequest) (*dto.CollectionResponse, error) {
+	if !collectionNamePattern.MatchString(input.Name) {
+		return nil, fmt.Errorf("invalid name: use 1-63 lowercase letters, digits, '-' or '_'")
+	}
+
+	if existing, err := s.collectionRepo.GetCollection(ctx, input.Name); err != nil {
+		return nil, err
+	} else if existing != nil {
+		return nil, fmt.Errorf("conflict: collection already exists")
+	}
+
+	created, err := s.collectionRepo.CreateCollection(ctx, models.Collection{
+		Name:      input.Name,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := toCollectionResponse(*created)
+	return &response, nil
+}
+
+func (s *collectionService) GetCollection(ctx context.Context, name string) (*dto.CollectionResponse, error) {
+	collection, err := s.collectionRepo.GetCollection(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if collection == nil {
+		return nil, fmt.Errorf("not found: collection does not exist")
+	}
+
+	response := toCollectionResponse(*collection)
+	return &response, nil
+}
+
+func (s *collectionService) ListCollections(ctx context.Context) (*dto.ListCollectionsResponse, error) {
+	collections, err := s.collectionRepo.ListCollections(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []dto.CollectionResponse{}
+	for _, collection := range *collections {
+		data = append(data, toCollectionResponse(collection))
+	}
+
+	return &dto.ListCollectionsResponse{
+		Data:  data,
+		Count: len(data),
+	}, nil
+}
+
+func (s *collectionService) DeleteCollection(ctx context.Context, name string) error {
+	if name == DefaultCollection {
+		return fmt.Errorf("invalid name: the default collection cannot be deleted")
+	}
+
+	existing, err := s.collectionRepo.GetCollection(ctx, name)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return fmt.Errorf("not found: collection does not exist")
+	}
+
+	// Refuse to orphan strings, including those still in the trash
+	count, err := s.collectionRepo.CountStrings(ctx, name)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("conflict: collection still holds %d strings", count)
+	}
+
+	return s.collectionRepo.DeleteCollection(ctx, name)
+}
+
+// CollectionVersion returns a number that changes whenever a string in the
+// collection is created, changed or removed
+func (s *collectionService) CollectionVersion(ctx context.Context, name string) (int64, error) {
+	collection, err := s.collectionRepo.GetCollection(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	if collection == nil {
+		return 0, fmt.Errorf("not found: collection does not exist")
+	}
+	return collection.Version, nil
+}
+
+func toCollectionResponse(collection models.Collection) dto.CollectionResponse {
+	return dto.CollectionResponse{
+		Name:      collection.Name,
+		CreatedAt: collection.CreatedAt.Format(time.RFC3339),
+	}
+}
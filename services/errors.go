@@ -0,0 +1,15 @@
+package services
+
+import "task_one/repository"
+
+// ErrCanceled and ErrTimeout wrap errors from work abandoned because the
+// request's context ended, either by the client going away or by the
+// configured query timeout
+var (
+	ErrCanceled = repository.ErrCanceled
+	ErrTimeout  = repository.ErrTimeout
+)
+
+// ErrPreconditionFailed is returned by a write whose precondition did not
+// hold for the stored entry
+var ErrPreconditionFailed = repository.ErrPreconditionFailed
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"task_one/buildinfo"
+	"task_one/dto"
+	"task_one/migrations"
+	"task_one/repository"
+	"time"
+)
+
+// readinessTimeout bounds the database checks so a hung database fails the
+// probe instead of stalling it
+const readinessTimeout = 2 * time.Second
+
+type HealthService interface {
+	Readiness(ctx context.Context) (*dto.ReadinessResponse, bool)
+	Version(ctx context.Context) *dto.VersionResponse
+	// SetDraining marks the service as shutting down so readiness fails
+	SetDraining()
+}
+
+type healthService struct {
+	healthRepo repository.HealthRepository
+	parser     NaturalLanguageParser
+	draining   atomic.Bool
+}
+
+func NewHealthService(healthRepo repository.HealthRepository, parser NaturalLanguageParser) HealthService {
+	return &healthService{
+		healthRepo: healthRepo,
+		parser:     parser,
+	}
+}
+
+// Readiness runs every readiness check and reports whether all passed
+func (s *healthService) Readiness(ctx context.Context) (*dto.ReadinessResponse, bool) {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	checks := make(map[string]string)
+	ready := true
+	fail := func(name, reason string) {
+		checks[name] = reason
+		ready = false
+	}
+
+	if s.draining.Load() {
+		fail("server", "draining")
+	} else {
+		checks["server"] = "ok"
+	}
+
+	if err := s.healthRepo.Ping(ctx); err != nil {
+		fail("database", err.Error())
+	} else {
+		checks["database"] = "ok"
+	}
+
+	if version, err := s.healthRepo.SchemaVersion(ctx); err != nil {
+		fail("migrations", err.Error())
+	} else if version < migrations.Latest() {
+		fail("migrations", fmt.Sprintf("schema is at version %d, expected %d", version, migrations.Latest()))
+	} else {
+		checks["migrations"] = "ok"
+	}
+
+	if err := s.parser.CheckRules(); err != nil {
+		fail("parser", err.Error())
+	} else {
+		checks["parser"] = "ok"
+	}
+
+	status := "ready"
+	if !ready {
+		status = "not ready"
+	}
+	return &dto.ReadinessResponse{Status: status, Checks: checks}, ready
+}
+
+// Version describes the build and the schema versions. The applied version
+// is left out when the database cannot be asked.
+func (s *healthService) Version(ctx context.Context) *dto.VersionResponse {
+	info := buildinfo.Get()
+	response := &dto.VersionResponse{
+		GitSHA:        info.GitSHA,
+		BuildTime:     info.BuildTime,
+		GoVersion:     info.GoVersion,
+		SchemaVersion: migrations.Latest(),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+	if applied, err := s.healthRepo.SchemaVersion(ctx); err == nil {
+		response.AppliedSchemaVersion = &applied
+	}
+	return response
+}
+
+func (s *healthService) SetDraining() {
+	s.draining.Store(true)
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// IdempotencyPurger periodically removes idempotency keys, and the responses
+// stored with them, once they can no longer be replayed. It runs whatever the
+// trash retention, since the stored bodies would otherwise pile up.
+type IdempotencyPurger struct {
+	idempotencyService IdempotencyService
+	interval           time.Duration
+	logger             *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewIdempotencyPurger(idempotencyService IdempotencyService, interval time.Duration, logger *slog.Logger) *IdempotencyPurger {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &IdempotencyPurger{
+		idempotencyService: idempotencyService,
+		interval:           interval,
+		logger:             logger,
+		ctx:                ctx,
+		cancel:             cancel,
+	}
+}
+
+// Start runs the purge loop in the background. It is a no-op when the
+// interval is not positive.
+func (p *IdempotencyPurger) Start() {
+	if p.interval <= 0 {
+		p.logger.Info("Idempotency key purge job disabled")
+		return
+	}
+	runEvery(p.ctx, &p.wg, p.interval, p.purge)
+}
+
+// Stop ends the purge loop, cancelling an in-flight purge, and waits for it
+// to return
+func (p *IdempotencyPurger) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *IdempotencyPurger) purge() {
+	expired, err := p.idempotencyService.PurgeExpired(p.ctx)
+	if err != nil {
+		if p.ctx.Err() != nil {
+			return
+		}
+		p.logger.ErrorContext(p.ctx, "Failed to purge idempotency keys", slog.Any("error", err))
+		return
+	}
+	if expired > 0 {
+		p.logger.Info("Purged expired idempotency keys", slog.Int64("count", expired))
+	}
+}
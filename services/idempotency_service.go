@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"task_one/dto"
+	"task_one/repository"
+	"time"
+)
+
+type IdempotencyService interface {
+	Begin(ctx context.Context, clientKey, key, fingerprint string) (*Reservation, *dto.StoredResponse, error)
+	Complete(ctx context.Context, reservation Reservation, response dto.StoredResponse)
+	Release(ctx context.Context, reservation Reservation)
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+// Reservation is a request's claim on an idempotency key. Completing or
+// releasing it does nothing once a retry has taken the key over.
+type Reservation struct {
+	ClientKey  string
+	Key        string
+	reservedAt time.Time
+}
+
+type idempotencyService struct {
+	idempotencyRepo repository.IdempotencyRepository
+	ttl             time.Duration
+	lease           time.Duration
+	logger          *slog.Logger
+}
+
+// NewIdempotencyService keeps responses for replay for ttl, after which the
+// key may be reused. A key held by a request that has not finished within
+// lease is given to the next retry.
+func NewIdempotencyService(idempotencyRepo repository.IdempotencyRepository, ttl, lease time.Duration, logger *slog.Logger) IdempotencyService {
+	return &idempotencyService{
+		idempotencyRepo: idempotencyRepo,
+		ttl:             ttl,
+		lease:           lease,
+		logger:          logger,
+	}
+}
+
+// Begin claims key for a request identified by fingerprint. It returns the
+// reservation when the request should run, or the stored response when a
+// previous attempt already completed. A key still held by a running request
+// is a conflict; a key reused for a different request is invalid.
+func (s *idempotencyService) Begin(ctx context.Context, clientKey, key, fingerprint string) (*Reservation, *dto.StoredResponse, error) {
+	now := time.Now().UTC()
+	reservedAt, reserved, err := s.idempotencyRepo.Reserve(ctx, clientKey, key, fingerprint, now.Add(-s.ttl), now.Add(-s.lease))
+	if err != nil {
+		return nil, nil, err
+	}
+	if reserved {
+		return &Reservation{ClientKey: clientKey, Key: key, reservedAt: reservedAt}, nil, nil
+	}
+
+	record, err := s.idempotencyRepo.Get(ctx, clientKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Released by the other request between the two statements
+	if record == nil {
+		return nil, nil, fmt.Errorf("conflict: idempotency key is in use")
+	}
+	if record.Fingerprint != fingerprint {
+		return nil, nil, fmt.Errorf("invalid idempotency key: already used for a different request")
+	}
+	if record.StatusCode == 0 {
+		return nil, nil, fmt.Errorf("conflict: idempotency key is in use")
+	}
+
+	headers := map[string]string{}
+	if len(record.Headers) > 0 {
+		if err := json.Unmarshal(record.Headers, &headers); err != nil {
+			return nil, nil, fmt.Errorf("failed to unmarshal stored headers: %v", err)
+		}
+	}
+	return nil, &dto.StoredResponse{StatusCode: record.StatusCode, Headers: headers, Body: record.Body}, nil
+}
+
+// Complete stores the response for replay. A failure only costs the replay,
+// so it is logged rather than returned.
+func (s *idempotencyService) Complete(ctx context.Context, reservation Reservation, response dto.StoredResponse) {
+	headers, _ := json.Marshal(response.Headers)
+	if err := s.idempotencyRepo.Complete(ctx, reservation.ClientKey, reservation.Key, reservation.reservedAt, response.StatusCode, headers, response.Body); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to store idempotent response", slog.String("client", reservation.ClientKey), slog.Any("error", err))
+	}
+}
+
+// Release gives up the key so the client can retry with it
+func (s *idempotencyService) Release(ctx context.Context, reservation Reservation) {
+	if err := s.idempotencyRepo.Release(ctx, reservation.ClientKey, reservation.Key, reservation.reservedAt); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to release idempotency key", slog.String("client", reservation.ClientKey), slog.Any("error", err))
+	}
+}
+
+// PurgeExpired deletes keys older than the ttl
+func (s *idempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.idempotencyRepo.PurgeBefore(ctx, time.Now().UTC().Add(-s.ttl))
+}
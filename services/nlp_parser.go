@@ -5,17 +5,142 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"task_one/analysis"
 	"task_one/dto"
+	"task_one/metrics"
+	"unicode"
 )
 
 type NaturalLanguageParser interface {
 	ParseQuery(query string) (*dto.FilterByCriteriaData, *dto.InterpretedQuery, error)
+	// CheckRules reports an error unless every loaded rule recognises its
+	// example query
+	CheckRules() error
 }
 
-type naturalLanguageParser struct{}
+// parseRule recognises one phrase of a normalized query and records the
+// filter it implies. apply reports whether the rule matched. example is a
+// normalized query the rule must match.
+type parseRule struct {
+	name    string
+	example string
+	apply   func(p *naturalLanguageParser, query string, words []string, filters *dto.FilterByCriteriaData, parsedFilters map[string]any) bool
+}
+
+var (
+	longerThanPattern    = regexp.MustCompile(`longer than (\d+) characters?`)
+	containLetterPattern = regexp.MustCompile(`contain(?:ing)? the letter ([a-z])`)
+)
+
+var defaultParseRules = []parseRule{
+	// Pattern 1: "single word" -> word_count = 1
+	{name: "single_word", example: "single word strings", apply: func(p *naturalLanguageParser, query string, words []string, filters *dto.FilterByCriteriaData, parsedFilters map[string]any) bool {
+		if p.containsPhrase(words, "single", "word") {
+			wordCount := 1
+			filters.WordCount = &wordCount
+			parsedFilters["word_count"] = wordCount
+			return true
+		}
+		return false
+	}},
+	// Pattern 2: "palindromic" -> is_palindrome = true
+	{name: "palindromic", example: "palindromic strings", apply: func(p *naturalLanguageParser, query string, words []string, filters *dto.FilterByCriteriaData, parsedFilters map[string]any) bool {
+		if p.containsWord(words, "palindromic") {
+			isPalindrome := true
+			filters.IsPalindrome = &isPalindrome
+			parsedFilters["is_palindrome"] = isPalindrome
+			return true
+		}
+		return false
+	}},
+	// Pattern 3: "longer than X characters" -> min_length = X + 1
+	{name: "longer_than", example: "strings longer than 10 characters", apply: func(p *naturalLanguageParser, query string, words []string, filters *dto.FilterByCriteriaData, parsedFilters map[string]any) bool {
+		matches := longerThanPattern.FindStringSubmatch(query)
+		if len(matches) > 1 {
+			if length, err := strconv.Atoi(matches[1]); err == nil {
+				minLength := length + 1
+				filters.MinLength = &minLength
+				parsedFilters["min_length"] = minLength
+				return true
+			}
+		}
+		return false
+	}},
+	// Pattern 4: "containing the letter X" or "contain the letter X" -> contains_character = X
+	{name: "contains_letter", example: "strings containing the letter z", apply: func(p *naturalLanguageParser, query string, words []string, filters *dto.FilterByCriteriaData, parsedFilters map[string]any) bool {
+		matches := containLetterPattern.FindStringSubmatch(query)
+		if len(matches) > 1 {
+			character := matches[1]
+			filters.ContainsCharacter = &character
+			parsedFilters["contains_character"] = character
+			return true
+		}
+		return false
+	}},
+	// Pattern 5: "containing the first vowel" -> contains_character = a
+	{name: "first_vowel", example: "strings containing the first vowel", apply: func(p *naturalLanguageParser, query string, words []string, filters *dto.FilterByCriteriaData, parsedFilters map[string]any) bool {
+		if p.containsPhrase(words, "first", "vowel") && p.containsWord(words, "containing") {
+			character := "a"
+			filters.ContainsCharacter = &character
+			parsedFilters["contains_character"] = character
+			return true
+		}
+		return false
+	}},
+	// Pattern 6: "strings in french" or "french strings" -> language = fr
+	{name: "language", example: "strings in french", apply: func(p *naturalLanguageParser, query string, words []string, filters *dto.FilterByCriteriaData, parsedFilters map[string]any) bool {
+		for i, word := range words {
+			code, ok := analysis.LanguageCode(strings.TrimFunc(word, unicode.IsPunct))
+			// "greek script" names the script, not the language
+			if !ok || p.followedBy(words, i, scriptNouns...) {
+				continue
+			}
+			setPropertyFilter(filters, "language", code)
+			parsedFilters["language"] = code
+			return true
+		}
+		return false
+	}},
+	// Pattern 7: "cyrillic strings" or "strings in greek script" -> script = cyrillic, greek
+	{name: "script", example: "cyrillic strings", apply: func(p *naturalLanguageParser, query string, words []string, filters *dto.FilterByCriteriaData, parsedFilters map[string]any) bool {
+		for i, word := range words {
+			word = strings.TrimFunc(word, unicode.IsPunct)
+			script, ok := analysis.ParseScript(word)
+			if !ok || script == analysis.ScriptOther || script == analysis.ScriptNone {
+				continue
+			}
+			// Script names that are also language names, and "mixed", need
+			// a noun after them
+			_, isLanguage := analysis.LanguageCode(word)
+			if (isLanguage || script == analysis.ScriptMixed) && !p.followedBy(words, i, scriptNouns...) {
+				continue
+			}
+			setPropertyFilter(filters, "script", string(script))
+			parsedFilters["script"] = string(script)
+			return true
+		}
+		return false
+	}},
+}
+
+// scriptNouns follow a script name, as in "latin script" or "greek letters"
+var scriptNouns = []string{"script", "alphabet", "characters", "letters"}
+
+// setPropertyFilter filters on the registered property name equalling value
+func setPropertyFilter(filters *dto.FilterByCriteriaData, name string, value any) {
+	if filters.Properties == nil {
+		filters.Properties = make(map[string]dto.PropertyFilter)
+	}
+	filters.Properties[name] = dto.PropertyFilter{Equals: value}
+}
 
-func NewNaturalLanguageParser() NaturalLanguageParser {
-	return &naturalLanguageParser{}
+type naturalLanguageParser struct {
+	rules   []parseRule
+	metrics *metrics.Metrics
+}
+
+func NewNaturalLanguageParser(m *metrics.Metrics) NaturalLanguageParser {
+	return &naturalLanguageParser{rules: defaultParseRules, metrics: m}
 }
 
 func (p *naturalLanguageParser) ParseQuery(query string) (*dto.FilterByCriteriaData, *dto.InterpretedQuery, error) {
@@ -26,11 +151,13 @@ func (p *naturalLanguageParser) ParseQuery(query string) (*dto.FilterByCriteriaD
 	// Parse different patterns
 	err := p.parsePatterns(normalizedQuery, filters, parsedFilters)
 	if err != nil {
+		p.metrics.ObserveParse("", metrics.ParseFailure)
 		return nil, nil, fmt.Errorf("unable to parse natural language query: %v", err)
 	}
 
 	// Validate for conflicts
 	if err := p.validateFilters(filters); err != nil {
+		p.metrics.ObserveParse("", metrics.ParseFailure)
 		return nil, nil, fmt.Errorf("query parsed but resulted in conflicting filters: %v", err)
 	}
 
@@ -42,52 +169,34 @@ func (p *naturalLanguageParser) ParseQuery(query string) (*dto.FilterByCriteriaD
 	return filters, interpretedQuery, nil
 }
 
-func (p *naturalLanguageParser) parsePatterns(query string, filters *dto.FilterByCriteriaData, parsedFilters map[string]any) error {
-	words := strings.Fields(query)
-
-	// Pattern 1: "single word" -> word_count = 1
-	if p.containsPhrase(words, "single", "word") {
-		wordCount := 1
-		filters.WordCount = &wordCount
-		parsedFilters["word_count"] = wordCount
+// CheckRules runs each rule on its example. The language and script rules
+// rely on the tables in analysis, so a rule that stops matching its example
+// means queries that should filter are silently ignored.
+func (p *naturalLanguageParser) CheckRules() error {
+	if len(p.rules) == 0 {
+		return fmt.Errorf("no rules loaded")
 	}
-
-	// Pattern 2: "palindromic" -> is_palindrome = true
-	if p.containsWord(words, "palindromic") {
-		isPalindrome := true
-		filters.IsPalindrome = &isPalindrome
-		parsedFilters["is_palindrome"] = isPalindrome
-	}
-
-	// Pattern 3: "longer than X characters" -> min_length = X + 1
-	if longerThanPattern := regexp.MustCompile(`longer than (\d+) characters?`); longerThanPattern.MatchString(query) {
-		matches := longerThanPattern.FindStringSubmatch(query)
-		if len(matches) > 1 {
-			if length, err := strconv.Atoi(matches[1]); err == nil {
-				minLength := length + 1
-				filters.MinLength = &minLength
-				parsedFilters["min_length"] = minLength
-			}
+	for _, rule := range p.rules {
+		words := strings.Fields(rule.example)
+		if !rule.apply(p, rule.example, words, &dto.FilterByCriteriaData{}, map[string]any{}) {
+			return fmt.Errorf("rule %s does not match %q", rule.name, rule.example)
 		}
 	}
+	return nil
+}
 
-	// Pattern 4: "containing the letter X" or "contain the letter X" -> contains_character = X
-	if containLetterPattern := regexp.MustCompile(`contain(?:ing)? the letter ([a-z])`); containLetterPattern.MatchString(query) {
-		matches := containLetterPattern.FindStringSubmatch(query)
-		if len(matches) > 1 {
-			character := matches[1]
-			filters.ContainsCharacter = &character
-			parsedFilters["contains_character"] = character
+func (p *naturalLanguageParser) parsePatterns(query string, filters *dto.FilterByCriteriaData, parsedFilters map[string]any) error {
+	words := strings.Fields(query)
+	matched := false
+	for _, rule := range p.rules {
+		if rule.apply(p, query, words, filters, parsedFilters) {
+			p.metrics.ObserveParse(rule.name, metrics.ParseMatched)
+			matched = true
 		}
 	}
-
-	// Pattern 5: "containing the first vowel" -> contains_character = a
-	if p.containsPhrase(words, "first", "vowel") && p.containsWord(words, "containing") {
-		character := "a"
-		filters.ContainsCharacter = &character
-		parsedFilters["contains_character"] = character
+	if !matched {
+		p.metrics.ObserveParse("", metrics.ParseNoMatch)
 	}
-
 	return nil
 }
 
@@ -120,6 +229,19 @@ func (p *naturalLanguageParser) containsPhrase(words []string, phrase ...string)
 	return false
 }
 
+// followedBy reports whether the word after words[i] is one of next
+func (p *naturalLanguageParser) followedBy(words []string, i int, next ...string) bool {
+	if i+1 >= len(words) {
+		return false
+	}
+	for _, word := range next {
+		if strings.TrimFunc(words[i+1], unicode.IsPunct) == word {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *naturalLanguageParser) validateFilters(filters *dto.FilterByCriteriaData) error {
 	// Check for min_length > max_length
 	if filters.MinLength != nil && filters.MaxLength != nil {
@@ -0,0 +1,28 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// runEvery calls run at once and then every interval until ctx ends, in a
+// goroutine tracked by wg
+func runEvery(ctx context.Context, wg *sync.WaitGroup, interval time.Duration, run func()) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		run()
+		for {
+			select {
+			case <-ticker.C:
+				run()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,76 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"task_one/dto"
+	"task_one/repository"
+	"time"
+)
+
+type QuotaService interface {
+	ConsumeDailyCreation(ctx context.Context, clientKey string) (*dto.QuotaStatus, error)
+	RefundDailyCreation(ctx context.Context, clientKey string, day time.Time)
+}
+
+type quotaService struct {
+	quotaRepo  repository.QuotaRepository
+	dailyLimit int64
+	logger     *slog.Logger
+}
+
+// NewQuotaService limits every client to dailyLimit created strings per UTC
+// day. A limit of 0 disables the quota.
+func NewQuotaService(quotaRepo repository.QuotaRepository, dailyLimit int, logger *slog.Logger) QuotaService {
+	return &quotaService{
+		quotaRepo:  quotaRepo,
+		dailyLimit: int64(dailyLimit),
+		logger:     logger,
+	}
+}
+
+// ConsumeDailyCreation reserves one creation from the client's daily quota.
+// It returns nil when quotas are disabled.
+func (s *quotaService) ConsumeDailyCreation(ctx context.Context, clientKey string) (*dto.QuotaStatus, error) {
+	if s.dailyLimit <= 0 {
+		return nil, nil
+	}
+
+	day := today()
+	count, err := s.quotaRepo.IncrementDailyCount(ctx, clientKey, day)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &dto.QuotaStatus{
+		Limit:     s.dailyLimit,
+		Remaining: max(0, s.dailyLimit-count),
+		ResetAt:   day.AddDate(0, 0, 1),
+		Day:       day,
+	}
+	if count > s.dailyLimit {
+		// Give the reservation back so the counter reflects real creations
+		if err := s.quotaRepo.DecrementDailyCount(ctx, clientKey, day); err != nil {
+			s.logger.ErrorContext(ctx, "Failed to release quota reservation", slog.String("client", clientKey), slog.Any("error", err))
+		}
+		return status, fmt.Errorf("quota exceeded: %d strings per day", s.dailyLimit)
+	}
+	return status, nil
+}
+
+// RefundDailyCreation releases a reservation whose creation did not happen.
+// day is the one the reservation was charged to, which is not today when
+// the request ran past midnight.
+func (s *quotaService) RefundDailyCreation(ctx context.Context, clientKey string, day time.Time) {
+	if s.dailyLimit <= 0 {
+		return
+	}
+	if err := s.quotaRepo.DecrementDailyCount(ctx, clientKey, day); err != nil {
+		s.logger.ErrorContext(ctx, "Failed to refund quota reservation", slog.String("client", clientKey), slog.Any("error", err))
+	}
+}
+
+func today() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"task_one/dto"
+	"task_one/models"
+	"time"
+)
+
+// Recompute job states
+const (
+	RecomputeIdle      = "idle"
+	RecomputeRunning   = "running"
+	RecomputeCompleted = "completed"
+	RecomputeCancelled = "cancelled"
+	RecomputeFailed    = "failed"
+)
+
+// RecomputeJob runs at most one recompute at a time in the background and
+// remembers the progress of the latest one
+type RecomputeJob struct {
+	stringService StringService
+	logger        *slog.Logger
+
+	mu     sync.Mutex
+	status dto.RecomputeStatus
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewRecomputeJob(stringService StringService, logger *slog.Logger) *RecomputeJob {
+	return &RecomputeJob{
+		stringService: stringService,
+		logger:        logger,
+		status:        dto.RecomputeStatus{State: RecomputeIdle, AnalyzerVersion: models.CurrentAnalyzerVersion},
+	}
+}
+
+// Start begins a recompute with options unless one is already running
+func (j *RecomputeJob) Start(options RecomputeOptions) (dto.RecomputeStatus, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.status.State == RecomputeRunning {
+		return j.status, fmt.Errorf("conflict: a recompute is already running")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	startedAt := time.Now().UTC()
+	j.cancel = cancel
+	j.status = dto.RecomputeStatus{
+		State:           RecomputeRunning,
+		AnalyzerVersion: models.CurrentAnalyzerVersion,
+		BatchSize:       options.BatchSize,
+		Pause:           options.Pause.String(),
+		StartedAt:       &startedAt,
+	}
+
+	j.wg.Add(1)
+	go func() {
+		defer j.wg.Done()
+		defer cancel()
+		j.run(ctx, options)
+	}()
+	return j.status, nil
+}
+
+func (j *RecomputeJob) run(ctx context.Context, options RecomputeOptions) {
+	j.logger.Info("Recompute started",
+		slog.Int("analyzer_version", models.CurrentAnalyzerVersion),
+		slog.Int("batch_size", options.BatchSize),
+		slog.Duration("pause", options.Pause))
+
+	progress, err := j.stringService.Recompute(ctx, options, func(progress dto.RecomputeProgress) {
+		j.mu.Lock()
+		j.status.Progress = progress
+		j.mu.Unlock()
+		j.logger.Debug("Recompute progress", slog.Int64("processed", progress.Processed), slog.Int64("total", progress.Total))
+	})
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	finishedAt := time.Now().UTC()
+	j.status.Progress = progress
+	j.status.FinishedAt = &finishedAt
+	attrs := []any{slog.Int64("processed", progress.Processed), slog.Int64("updated", progress.Updated)}
+	switch {
+	case err == nil:
+		j.status.State = RecomputeCompleted
+		j.logger.Info("Recompute completed", attrs...)
+	case ctx.Err() != nil:
+		j.status.State = RecomputeCancelled
+		j.logger.Info("Recompute cancelled", attrs...)
+	default:
+		j.status.State = RecomputeFailed
+		j.status.Error = err.Error()
+		j.logger.Error("Recompute failed", append(attrs, slog.Any("error", err))...)
+	}
+}
+
+// Status reports the running or latest recompute
+func (j *RecomputeJob) Status() dto.RecomputeStatus {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.status
+}
+
+// Cancel stops the running recompute and waits for it. Batches already
+// saved are kept.
+func (j *RecomputeJob) Cancel() (dto.RecomputeStatus, error) {
+	j.mu.Lock()
+	if j.status.State != RecomputeRunning {
+		j.mu.Unlock()
+		return j.Status(), fmt.Errorf("not found: no recompute is running")
+	}
+	j.cancel()
+	j.mu.Unlock()
+
+	j.wg.Wait()
+	return j.Status(), nil
+}
+
+// Stop cancels a running recompute and waits for it to return, for shutdown
+func (j *RecomputeJob) Stop() {
+	j.mu.Lock()
+	if j.cancel != nil {
+		j.cancel()
+	}
+	j.mu.Unlock()
+	j.wg.Wait()
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"testing"
+	"time"
+
+	"task_one/models"
+)
+
+// waitForRecompute polls the job until done accepts its status
+func waitForRecompute(t *testing.T, job *RecomputeJob, done func(state string, processed int64) bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		status := job.Status()
+		if done(status.State, status.Progress.Processed) {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("recompute did not get there: %+v", job.Status())
+}
+
+func TestRecomputeResumesAfterCancel(t *testing.T) {
+	repo := newFakeStringRepository()
+	for i := range 5 {
+		value := fmt.Sprintf("value %d", i)
+		id := fmt.Sprintf("%064d", i)
+		repo.entries[DefaultCollection+"/"+id] = models.StringEntry{Collection: DefaultCollection, ID: id, Value: value, AnalyzerVersion: 1}
+	}
+	job := NewRecomputeJob(newTestStringService(repo), slog.New(slog.DiscardHandler))
+	defer job.Stop()
+
+	// The pause holds the job after the first batch until it is cancelled
+	if _, err := job.Start(RecomputeOptions{BatchSize: 2, Pause: time.Hour}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRecompute(t, job, func(state string, processed int64) bool { return processed == 2 })
+	status, err := job.Cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.State != RecomputeCancelled || status.Progress.Processed != 2 {
+		t.Fatalf("after cancel %+v, want cancelled with 2 processed", status)
+	}
+
+	if _, err := job.Start(RecomputeOptions{BatchSize: 2}); err != nil {
+		t.Fatal(err)
+	}
+	waitForRecompute(t, job, func(state string, processed int64) bool { return state != RecomputeRunning })
+	status = job.Status()
+	if status.State != RecomputeCompleted || status.Progress.Total != 3 || status.Progress.Processed != 3 || status.Progress.Updated != 3 {
+		t.Fatalf("second run %+v, want the 3 entries left", status)
+	}
+	for key, entry := range repo.entries {
+		if entry.AnalyzerVersion != models.CurrentAnalyzerVersion || repo.saves[key] != 1 {
+			t.Errorf("%s: analyzer version %d saved %d times, want %d once", key, entry.AnalyzerVersion, repo.saves[key], models.CurrentAnalyzerVersion)
+		}
+	}
+}
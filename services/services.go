@@ -1,93 +1,210 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
+	"strconv"
+	"strings"
+	"task_one/analysis"
 	"task_one/dto"
+	"task_one/metrics"
 	"task_one/models"
 	"task_one/repository"
 	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/datatypes"
 )
 
+// tracer starts the spans for work done outside the database
+var tracer = otel.Tracer("task_one/services")
+
 type StringService interface {
-	CreateNewString(input dto.CreateNewStringEntryRequest) (*dto.CreateNewStringResponse, error)
-	GetStringByValue(value string) (*dto.GetStringByValueResponse, error)
-	FilterByCriteria(input dto.FilterByCriteriaData) (*dto.FilterByCriteriaResponse, error)
-	FilterByNaturalLanguage(input dto.FilterByNaturalLanguageRequest) (*dto.FilterByNaturalLanguageResponse, error)
-	DeleteStringEntry(value string) error
+	CreateNewString(ctx context.Context, collection string, input dto.CreateNewStringEntryRequest) (*dto.CreateNewStringResponse, error)
+	EnsureString(ctx context.Context, collection string, input dto.CreateNewStringEntryRequest) (*dto.CreateNewStringResponse, bool, error)
+	GetStringByValue(ctx context.Context, collection, value string) (*dto.GetStringByValueResponse, error)
+	GetStringById(ctx context.Context, collection, id string) (*dto.GetStringByValueResponse, error)
+	UpdateStringEntry(ctx context.Context, collection, id string, input dto.UpdateStringEntryRequest, precondition Precondition) (*dto.GetStringByValueResponse, error)
+	FilterByCriteria(ctx context.Context, collection string, input dto.FilterByCriteriaData) (*dto.FilterByCriteriaResponse, error)
+	FilterByNaturalLanguage(ctx context.Context, collection string, input dto.FilterByNaturalLanguageRequest) (*dto.FilterByNaturalLanguageResponse, error)
+	ExplainFilter(ctx context.Context, collection string, input dto.FilterByCriteriaData, analyze bool) (*dto.ExplainFilterResponse, error)
+	Stats(ctx context.Context, collection string, top int) (*dto.StringStatsResponse, error)
+	DeleteStringEntry(ctx context.Context, collection, value string, precondition Precondition) error
+	DeleteStringEntryById(ctx context.Context, collection, id string, precondition Precondition) error
+	ListTrash(ctx context.Context, collection string) (*dto.TrashListResponse, error)
+	RestoreStringEntry(ctx context.Context, collection, id string) (*dto.GetStringByValueResponse, error)
+	Recompute(ctx context.Context, options RecomputeOptions, report func(dto.RecomputeProgress)) (dto.RecomputeProgress, error)
 }
 
 type stringService struct {
-	stringRepo repository.StringRepository
+	stringRepo  repository.StringRepository
+	parser      NaturalLanguageParser
+	valuePolicy ValuePolicy
+	metrics     *metrics.Metrics
+	logger      *slog.Logger
 }
 
-func NewStringService(stringRepo repository.StringRepository) StringService {
+func NewStringService(stringRepo repository.StringRepository, parser NaturalLanguageParser, valuePolicy ValuePolicy, m *metrics.Metrics, logger *slog.Logger) StringService {
 	return &stringService{
-		stringRepo: stringRepo,
+		stringRepo:  stringRepo,
+		parser:      parser,
+		valuePolicy: valuePolicy,
+		metrics:     m,
+		logger:      logger,
 	}
 }
 
-func (s *stringService) CreateNewString(input dto.CreateNewStringEntryRequest) (*dto.CreateNewStringResponse, error) {
-	// Check for duplicates by value
-	if existing, err := s.stringRepo.GetStringByValue(input.Value); err != nil {
+func (s *stringService) CreateNewString(ctx context.Context, collection string, input dto.CreateNewStringEntryRequest) (*dto.CreateNewStringResponse, error) {
+	response, created, err := s.storeString(ctx, collection, input)
+	if err != nil {
 		return nil, err
-	} else if existing != nil {
+	}
+	if !created {
 		return nil, fmt.Errorf("conflict: string already exists")
 	}
+	return response, nil
+}
+
+// EnsureString creates the string unless it already exists, in which case
+// the stored entry is returned untouched. The bool reports whether it was
+// created.
+func (s *stringService) EnsureString(ctx context.Context, collection string, input dto.CreateNewStringEntryRequest) (*dto.CreateNewStringResponse, bool, error) {
+	response, created, err := s.storeString(ctx, collection, input)
+	if err != nil || created {
+		return response, created, err
+	}
+
+	existing, err := s.stringRepo.GetStringByHash(ctx, collection, response.Id)
+	if err != nil {
+		return nil, false, err
+	}
+	// Deleted between the insert and the read
+	if existing == nil {
+		return nil, false, fmt.Errorf("conflict: string was deleted while being stored")
+	}
+	item, err := toStringResponse(*existing)
+	if err != nil {
+		return nil, false, err
+	}
+	return &dto.CreateNewStringResponse{
+		Id:         item.Id,
+		Value:      item.Value,
+		Properties: item.Properties,
+		Tags:       item.Tags,
+		Metadata:   item.Metadata,
+		CreatedBy:  item.CreatedBy,
+		CreatedAt:  existing.CreatedAt,
+	}, false, nil
+}
+
+// storeString analyzes input and inserts it. When a live entry already holds
+// the value nothing is stored, false is returned and only the response's Id
+// is meaningful.
+func (s *stringService) storeString(ctx context.Context, collection string, input dto.CreateNewStringEntryRequest) (*dto.CreateNewStringResponse, bool, error) {
+	// Enforce size and content limits before anything touches the database
+	value, err := s.valuePolicy.Apply(input.Value)
+	if err != nil {
+		return nil, false, err
+	}
+	input.Value = value
+
 	// Compute string details
-	stringDetails := models.StringDetails{
-		Hash:         GetHash(input.Value),
-		Length:       getLength(input.Value),
-		IsPalindrome: getIsPalindrome(input.Value),
-		UniqueChars:  getUniqueCharsCount(input.Value),
-		WordCount:    getWordCount(input.Value),
-		FreqMap:      getCharFreqMap(input.Value),
+	s.metrics.ObserveInputSize(len(input.Value))
+	stringDetails := s.analyze(ctx, input.Value)
+
+	tags, err := normalizeTags(input.Tags, s.valuePolicy)
+	if err != nil {
+		return nil, false, err
+	}
+	metadata, err := normalizeMetadata(input.Metadata, s.valuePolicy)
+	if err != nil {
+		return nil, false, err
 	}
 
 	// Prepare DB entry
-	freqMapJSON, _ := json.Marshal(stringDetails.FreqMap)
+	tagsJSON, _ := json.Marshal(tags)
+	metadataJSON, _ := json.Marshal(metadata)
 	now := time.Now().UTC()
 	stringEntry := models.StringEntry{
-		ID:                    stringDetails.Hash,
-		Value:                 input.Value,
-		Length:                stringDetails.Length,
-		IsPalindrome:          stringDetails.IsPalindrome,
-		UniqueCharacters:      stringDetails.UniqueChars,
-		WordCount:             stringDetails.WordCount,
-		SHA256Hash:            stringDetails.Hash,
-		CharacterFrequencyMap: freqMapJSON,
-		CreatedAt:             now,
-	}
-	// Persist
-	_, err := s.stringRepo.CreateNewStringRecord(stringEntry)
-	if err != nil {
-		log.Println("Failed to create new string record", err)
-		return nil, err
+		Collection: collection,
+		ID:         stringDetails.Hash,
+		Value:      input.Value,
+		Tags:       tagsJSON,
+		Metadata:   metadataJSON,
+		CreatedBy:  input.CreatedBy,
+		CreatedAt:  now,
+	}
+	stringEntry.SetDetails(stringDetails)
+	// Persist. Duplicates are caught by the insert itself, which a separate
+	// lookup could not do for concurrent requests.
+	created, err := s.stringRepo.CreateNewStringRecord(ctx, stringEntry)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "Failed to create new string record", slog.String("collection", collection), slog.Any("error", err))
+		return nil, false, err
+	}
+	if !created {
+		return &dto.CreateNewStringResponse{Id: stringEntry.ID}, false, nil
 	}
 
 	finalResponse := dto.CreateNewStringResponse{
-		Id:    stringEntry.ID,
-		Value: input.Value,
-		Properties: dto.StringProperties{
-			Length:       stringDetails.Length,
-			IsPalindrome: stringDetails.IsPalindrome,
-			UniqueChars:  stringDetails.UniqueChars,
-			WordCount:    stringDetails.WordCount,
-			SHA256Hash:   stringDetails.Hash,
-			FreqMap:      stringDetails.FreqMap,
-		},
-		CreatedAt: now,
+		Id:         stringEntry.ID,
+		Value:      input.Value,
+		Properties: toStringProperties(stringEntry, stringDetails.FreqMap, stringDetails.Properties),
+		Tags:       tags,
+		Metadata:   metadata,
+		CreatedBy:  input.CreatedBy,
+		CreatedAt:  now,
+	}
+
+	return &finalResponse, true, nil
+}
+
+// analyze computes every property of value, timing each analyzer
+func (s *stringService) analyze(ctx context.Context, value string) models.StringDetails {
+	_, span := tracer.Start(ctx, "analyze", trace.WithAttributes(attribute.Int("input.bytes", len(value))))
+	defer span.End()
+
+	var details models.StringDetails
+	s.timeAnalyzer("sha256_hash", func() { details.Hash = GetHash(value) })
+	s.timeAnalyzer("length", func() { details.Length = getLength(value) })
+	s.timeAnalyzer("is_palindrome", func() { details.IsPalindrome = getIsPalindrome(value) })
+	s.timeAnalyzer("unique_characters", func() { details.UniqueChars = getUniqueCharsCount(value) })
+	s.timeAnalyzer("word_count", func() { details.WordCount = getWordCount(value) })
+	s.timeAnalyzer("character_frequency_map", func() { details.FreqMap = getCharFreqMap(value) })
+	s.timeAnalyzer("character_statistics", func() { details.Statistics = getCharacterStatistics(value) })
+
+	analyzers := analysis.Default().Analyzers()
+	details.Properties = make(map[string]any, len(analyzers))
+	details.PropertyVersions = make(map[string]int, len(analyzers))
+	for _, analyzer := range analyzers {
+		s.timeAnalyzer(analyzer.Name(), func() { details.Properties[analyzer.Name()] = analyzer.Analyze(value) })
+		details.PropertyVersions[analyzer.Name()] = analyzer.Version()
 	}
+	return details
+}
+
+func (s *stringService) timeAnalyzer(name string, analyzer func()) {
+	start := time.Now()
+	analyzer()
+	s.metrics.ObserveAnalysis(name, time.Since(start))
+}
 
-	return &finalResponse, nil
+func (s *stringService) GetStringByValue(ctx context.Context, collection, value string) (*dto.GetStringByValueResponse, error) {
+	// The SHA256 sum doubles as the entry id
+	return s.GetStringById(ctx, collection, GetHash(value))
 }
 
-func (s *stringService) GetStringByValue(value string) (*dto.GetStringByValueResponse, error) {
-	// Generate SHA256 sum
-	stringHash := GetHash(value)
+func (s *stringService) GetStringById(ctx context.Context, collection, id string) (*dto.GetStringByValueResponse, error) {
+	if !IsValidHash(id) {
+		return nil, fmt.Errorf("invalid id: must be a hex encoded SHA-256 hash")
+	}
 
-	stringData, err := s.stringRepo.GetStringById(stringHash)
+	stringData, err := s.stringRepo.GetStringById(ctx, collection, strings.ToLower(id))
 	if err != nil {
 		return nil, err
 	}
@@ -97,54 +214,95 @@ func (s *stringService) GetStringByValue(value string) (*dto.GetStringByValueRes
 		return nil, fmt.Errorf("not found: string does not exist in the system")
 	}
 
-	var freqMap map[string]int
-	if err := json.Unmarshal(stringData.CharacterFrequencyMap, &freqMap); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal frequency map: %v", err)
+	response, err := toStringResponse(*stringData)
+	if err != nil {
+		return nil, err
 	}
+	return &response, nil
+}
 
-	response := dto.GetStringByValueResponse{
-		Id:    stringData.ID,
-		Value: stringData.Value,
-		Properties: dto.StringProperties{
-			Length:       stringData.Length,
-			IsPalindrome: stringData.IsPalindrome,
-			UniqueChars:  stringData.UniqueCharacters,
-			WordCount:    stringData.WordCount,
-			FreqMap:      freqMap,
-		},
-		CreatedAt: stringData.CreatedAt.Format(time.RFC3339),
+// Precondition decides whether a write may go ahead, given the entry as it
+// is stored when the write runs. A nil Precondition always holds.
+type Precondition func(current *dto.GetStringByValueResponse) bool
+
+// entryPrecondition checks precondition against the response form of the
+// stored entry, which is what clients take their ETags from
+func entryPrecondition(precondition Precondition) repository.Precondition {
+	if precondition == nil {
+		return nil
 	}
-	return &response, nil
+	return func(current models.StringEntry) bool {
+		response, err := toStringResponse(current)
+		return err == nil && precondition(&response)
+	}
+}
+
+// UpdateStringEntry replaces the tags and/or metadata of an entry. It fails
+// with ErrPreconditionFailed, without writing, when precondition does not
+// hold.
+func (s *stringService) UpdateStringEntry(ctx context.Context, collection, id string, input dto.UpdateStringEntryRequest, precondition Precondition) (*dto.GetStringByValueResponse, error) {
+	if !IsValidHash(id) {
+		return nil, fmt.Errorf("invalid id: must be a hex encoded SHA-256 hash")
+	}
+	hashValue := strings.ToLower(id)
+
+	updates := make(map[string]any)
+	if input.Tags != nil {
+		tags, err := normalizeTags(*input.Tags, s.valuePolicy)
+		if err != nil {
+			return nil, err
+		}
+		tagsJSON, _ := json.Marshal(tags)
+		updates["tags"] = datatypes.JSON(tagsJSON)
+	}
+	if input.Metadata != nil {
+		metadata, err := normalizeMetadata(*input.Metadata, s.valuePolicy)
+		if err != nil {
+			return nil, err
+		}
+		metadataJSON, _ := json.Marshal(metadata)
+		updates["metadata"] = datatypes.JSON(metadataJSON)
+	}
+	if len(updates) == 0 {
+		return nil, fmt.Errorf("invalid update: provide tags and/or metadata")
+	}
+
+	updated, err := s.stringRepo.UpdateStringRecord(ctx, collection, hashValue, updates, entryPrecondition(precondition))
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, fmt.Errorf("not found: string does not exist in the system")
+	}
+
+	return s.GetStringById(ctx, collection, hashValue)
 }
 
-func (s *stringService) FilterByCriteria(input dto.FilterByCriteriaData) (*dto.FilterByCriteriaResponse, error) {
-	stringData, err := s.stringRepo.FilterByCriteria(input)
+func (s *stringService) FilterByCriteria(ctx context.Context, collection string, input dto.FilterByCriteriaData) (*dto.FilterByCriteriaResponse, error) {
+	stringData, err := s.stringRepo.FilterByCriteria(ctx, collection, input)
 	if err != nil {
 		return nil, err
 	}
 
 	var transformedData []dto.GetStringByValueResponse
 	for _, entry := range *stringData {
-		var freqMap map[string]int
-		if err := json.Unmarshal(entry.CharacterFrequencyMap, &freqMap); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal frequency map: %v", err)
-		}
-
-		transformedData = append(transformedData, dto.GetStringByValueResponse{
-			Id:    entry.ID,
-			Value: entry.Value,
-			Properties: dto.StringProperties{
-				Length:       entry.Length,
-				IsPalindrome: entry.IsPalindrome,
-				UniqueChars:  entry.UniqueCharacters,
-				WordCount:    entry.WordCount,
-				FreqMap:      freqMap,
-			},
-			CreatedAt: entry.CreatedAt.Format(time.RFC3339),
-		})
-	}
-
-	// Build filters_applied with only non-nil values
+		item, err := toStringResponse(entry)
+		if err != nil {
+			return nil, err
+		}
+		transformedData = append(transformedData, item)
+	}
+
+	response := dto.FilterByCriteriaResponse{
+		Data:           transformedData,
+		Count:          len(transformedData),
+		FiltersApplied: filtersApplied(input),
+	}
+	return &response, nil
+}
+
+// filtersApplied lists the filters set in input, keyed by query parameter
+func filtersApplied(input dto.FilterByCriteriaData) map[string]any {
 	filtersMap := make(map[string]any)
 	if input.IsPalindrome != nil {
 		filtersMap["is_palindrome"] = *input.IsPalindrome
@@ -161,25 +319,125 @@ func (s *stringService) FilterByCriteria(input dto.FilterByCriteriaData) (*dto.F
 	if input.ContainsCharacter != nil {
 		filtersMap["contains_character"] = *input.ContainsCharacter
 	}
+	if input.ContainsNGram != nil {
+		filtersMap["contains_ngram"] = *input.ContainsNGram
+	}
+	if input.AnalyzerVersion != nil {
+		filtersMap["analyzer_version"] = *input.AnalyzerVersion
+	}
+	if input.Stale != nil {
+		filtersMap["stale"] = *input.Stale
+	}
+	for name, filter := range input.Properties {
+		if filter.Equals != nil {
+			filtersMap[name] = filter.Equals
+		}
+		if filter.Min != nil {
+			filtersMap["min_"+name] = *filter.Min
+		}
+		if filter.Max != nil {
+			filtersMap["max_"+name] = *filter.Max
+		}
+	}
+	for name, bounds := range input.Ranges {
+		if bounds.Min != nil {
+			filtersMap["min_"+name] = *bounds.Min
+		}
+		if bounds.Max != nil {
+			filtersMap["max_"+name] = *bounds.Max
+		}
+	}
+	if len(input.Tags) > 0 {
+		filtersMap["tag"] = input.Tags
+	}
+	for key, value := range input.Metadata {
+		filtersMap["metadata."+key] = value
+	}
+	return filtersMap
+}
 
-	response := dto.FilterByCriteriaResponse{
-		Data:           transformedData,
-		Count:          len(transformedData),
-		FiltersApplied: filtersMap,
+// Stats counts the live strings of collection and lists the top most
+// frequent n-grams of every counted size
+func (s *stringService) Stats(ctx context.Context, collection string, top int) (*dto.StringStatsResponse, error) {
+	sizes := analysis.NGramSizes()
+	stats, err := s.stringRepo.Stats(ctx, collection, sizes, top)
+	if err != nil {
+		return nil, err
+	}
+
+	response := dto.StringStatsResponse{
+		Count:     stats.Count,
+		TopNGrams: make(map[string][]dto.NGramCount, len(sizes)),
+	}
+	for _, n := range sizes {
+		counts := make([]dto.NGramCount, 0, len(stats.TopNGrams[n]))
+		for _, count := range stats.TopNGrams[n] {
+			counts = append(counts, dto.NGramCount{NGram: count.NGram, Occurrences: count.Occurrences, Strings: count.Strings})
+		}
+		response.TopNGrams[strconv.Itoa(n)] = counts
 	}
 	return &response, nil
 }
 
-func (s *stringService) FilterByNaturalLanguage(input dto.FilterByNaturalLanguageRequest) (*dto.FilterByNaturalLanguageResponse, error) {
+func (s *stringService) ExplainFilter(ctx context.Context, collection string, input dto.FilterByCriteriaData, analyze bool) (*dto.ExplainFilterResponse, error) {
+	plan, err := s.stringRepo.ExplainFilter(ctx, collection, input, analyze)
+	if err != nil {
+		return nil, err
+	}
+
+	var nodes []any
+	if err := json.Unmarshal(plan.Plan, &nodes); err != nil {
+		return nil, fmt.Errorf("failed to read query plan: %v", err)
+	}
+
+	response := &dto.ExplainFilterResponse{
+		Query:          plan.Query,
+		FiltersApplied: filtersApplied(input),
+		IndexesUsed:    []string{},
+		Plan:           plan.Plan,
+	}
+	seen := make(map[string]bool)
+	walkPlan(nodes, func(node map[string]any) {
+		if index, ok := node["Index Name"].(string); ok && !seen[index] {
+			seen[index] = true
+			response.IndexesUsed = append(response.IndexesUsed, index)
+		}
+		if node["Node Type"] == "Seq Scan" {
+			response.SequentialScan = true
+		}
+	})
+	return response, nil
+}
+
+// walkPlan calls visit for every node of a JSON EXPLAIN plan, descending
+// into nested "Plans"
+func walkPlan(value any, visit func(node map[string]any)) {
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			walkPlan(item, visit)
+		}
+	case map[string]any:
+		if plan, ok := v["Plan"]; ok {
+			walkPlan(plan, visit)
+			return
+		}
+		visit(v)
+		if children, ok := v["Plans"]; ok {
+			walkPlan(children, visit)
+		}
+	}
+}
+
+func (s *stringService) FilterByNaturalLanguage(ctx context.Context, collection string, input dto.FilterByNaturalLanguageRequest) (*dto.FilterByNaturalLanguageResponse, error) {
 	// Parse the natural language query
-	parser := NewNaturalLanguageParser()
-	filters, interpretedQuery, err := parser.ParseQuery(input.Query)
+	filters, interpretedQuery, err := s.parseQuery(ctx, input.Query)
 	if err != nil {
 		return nil, err
 	}
 
 	// Use the existing FilterByCriteria method
-	criteriaResponse, err := s.FilterByCriteria(*filters)
+	criteriaResponse, err := s.FilterByCriteria(ctx, collection, *filters)
 	if err != nil {
 		return nil, err
 	}
@@ -200,19 +458,202 @@ func (s *stringService) FilterByNaturalLanguage(input dto.FilterByNaturalLanguag
 	return response, nil
 }
 
-func (s *stringService) DeleteStringEntry(value string) error {
+// parseQuery runs the natural language parser inside its own span
+func (s *stringService) parseQuery(ctx context.Context, query string) (*dto.FilterByCriteriaData, *dto.InterpretedQuery, error) {
+	_, span := tracer.Start(ctx, "nlp.parse", trace.WithAttributes(attribute.Int("query.length", len(query))))
+	defer span.End()
+
+	filters, interpretedQuery, err := s.parser.ParseQuery(query)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, "parse failed")
+		return nil, nil, err
+	}
+	span.SetAttributes(attribute.Int("query.filters", len(interpretedQuery.ParsedFilters)))
+	return filters, interpretedQuery, nil
+}
+
+func (s *stringService) DeleteStringEntry(ctx context.Context, collection, value string, precondition Precondition) error {
 	// Compute the hash
-	hashValue := GetHash(value)
+	return s.DeleteStringEntryById(ctx, collection, GetHash(value), precondition)
+}
 
-	// Check if it exists
-	existing, err := s.stringRepo.GetStringById(hashValue)
+// DeleteStringEntryById moves an entry to the trash. It fails with
+// ErrPreconditionFailed, without deleting, when precondition does not hold.
+func (s *stringService) DeleteStringEntryById(ctx context.Context, collection, id string, precondition Precondition) error {
+	if !IsValidHash(id) {
+		return fmt.Errorf("invalid id: must be a hex encoded SHA-256 hash")
+	}
+	hashValue := strings.ToLower(id)
+
+	deleted, err := s.stringRepo.DeleteStringValue(ctx, collection, hashValue, entryPrecondition(precondition))
 	if err != nil {
 		return err
 	}
-	if existing == nil {
+	if !deleted {
 		return fmt.Errorf("not found: string does not exist in the system")
 	}
+	return nil
+}
+
+func (s *stringService) ListTrash(ctx context.Context, collection string) (*dto.TrashListResponse, error) {
+	entries, err := s.stringRepo.ListDeleted(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []dto.GetStringByValueResponse{}
+	for _, entry := range *entries {
+		item, err := toStringResponse(entry)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, item)
+	}
 
-	// Delete the string
-	return s.stringRepo.DeleteStringValue(hashValue)
+	return &dto.TrashListResponse{
+		Data:  data,
+		Count: len(data),
+	}, nil
+}
+
+func (s *stringService) RestoreStringEntry(ctx context.Context, collection, id string) (*dto.GetStringByValueResponse, error) {
+	if !IsValidHash(id) {
+		return nil, fmt.Errorf("invalid id: must be a hex encoded SHA-256 hash")
+	}
+	hashValue := strings.ToLower(id)
+
+	restored, err := s.stringRepo.RestoreStringValue(ctx, collection, hashValue)
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, fmt.Errorf("not found: string is not in the trash")
+	}
+
+	return s.GetStringById(ctx, collection, hashValue)
+}
+
+// toStringResponse maps a stored entry onto its API representation
+func toStringResponse(entry models.StringEntry) (dto.GetStringByValueResponse, error) {
+	var freqMap map[string]int
+	if err := json.Unmarshal(entry.CharacterFrequencyMap, &freqMap); err != nil {
+		return dto.GetStringByValueResponse{}, fmt.Errorf("failed to unmarshal frequency map: %v", err)
+	}
+	tags := []string{}
+	if len(entry.Tags) > 0 {
+		if err := json.Unmarshal(entry.Tags, &tags); err != nil {
+			return dto.GetStringByValueResponse{}, fmt.Errorf("failed to unmarshal tags: %v", err)
+		}
+	}
+	metadata := map[string]any{}
+	if len(entry.Metadata) > 0 {
+		if err := json.Unmarshal(entry.Metadata, &metadata); err != nil {
+			return dto.GetStringByValueResponse{}, fmt.Errorf("failed to unmarshal metadata: %v", err)
+		}
+	}
+	properties := map[string]any{}
+	if len(entry.Properties) > 0 {
+		if err := json.Unmarshal(entry.Properties, &properties); err != nil {
+			return dto.GetStringByValueResponse{}, fmt.Errorf("failed to unmarshal properties: %v", err)
+		}
+	}
+
+	response := dto.GetStringByValueResponse{
+		Id:         entry.ID,
+		Value:      entry.Value,
+		Properties: toStringProperties(entry, freqMap, properties),
+		Tags:       tags,
+		Metadata:   metadata,
+		CreatedBy:  entry.CreatedBy,
+		CreatedAt:  entry.CreatedAt.Format(time.RFC3339),
+	}
+	if entry.DeletedAt.Valid {
+		response.DeletedAt = entry.DeletedAt.Time.Format(time.RFC3339)
+	}
+	return response, nil
+}
+
+// RecomputeOptions throttles a recompute
+type RecomputeOptions struct {
+	// BatchSize is the number of entries loaded and saved together
+	BatchSize int
+	// Pause is slept between batches to leave the database to other work
+	Pause time.Duration
+}
+
+// Recompute analyzes the entries stored by older analyzer versions again,
+// trashed ones included, calling report after every batch. Recomputed
+// entries leave the stale set, so an interrupted run resumes where it
+// stopped when started again.
+func (s *stringService) Recompute(ctx context.Context, options RecomputeOptions, report func(dto.RecomputeProgress)) (dto.RecomputeProgress, error) {
+	var progress dto.RecomputeProgress
+	total, err := s.stringRepo.CountStale(ctx)
+	if err != nil {
+		return progress, err
+	}
+	progress.Total = total
+
+	var after repository.StaleCursor
+	for {
+		entries, err := s.stringRepo.ListStale(ctx, after, options.BatchSize)
+		if err != nil {
+			return progress, err
+		}
+		if len(*entries) == 0 {
+			return progress, nil
+		}
+		// The cursor follows the stored version, which the analysis replaces
+		last := (*entries)[len(*entries)-1]
+		after = repository.StaleCursor{AnalyzerVersion: last.AnalyzerVersion, Collection: last.Collection, ID: last.ID}
+
+		for i := range *entries {
+			entry := &(*entries)[i]
+			entry.SetDetails(s.analyze(ctx, entry.Value))
+		}
+		updated, err := s.stringRepo.SaveAnalysis(ctx, *entries)
+		if err != nil {
+			return progress, err
+		}
+		progress.Processed += int64(len(*entries))
+		progress.Updated += updated
+		if report != nil {
+			report(progress)
+		}
+
+		if options.Pause > 0 {
+			select {
+			case <-time.After(options.Pause):
+			case <-ctx.Done():
+				return progress, ctx.Err()
+			}
+		}
+	}
+}
+
+// toStringProperties copies the analyzed properties of entry. freqMap and
+// the registered properties are passed decoded since callers already hold
+// them.
+func toStringProperties(entry models.StringEntry, freqMap map[string]int, registered map[string]any) dto.StringProperties {
+	return dto.StringProperties{
+		Length:           entry.Length,
+		IsPalindrome:     entry.IsPalindrome,
+		UniqueChars:      entry.UniqueCharacters,
+		WordCount:        entry.WordCount,
+		SHA256Hash:       entry.SHA256Hash,
+		FreqMap:          freqMap,
+		AnalyzerVersion:  entry.AnalyzerVersion,
+		Entropy:          entry.Entropy,
+		LetterCount:      entry.LetterCount,
+		DigitCount:       entry.DigitCount,
+		WhitespaceCount:  entry.WhitespaceCount,
+		PunctuationCount: entry.PunctuationCount,
+		SymbolCount:      entry.SymbolCount,
+		UppercaseCount:   entry.UppercaseCount,
+		LowercaseCount:   entry.LowercaseCount,
+		VowelCount:       entry.VowelCount,
+		ConsonantCount:   entry.ConsonantCount,
+		LongestRun:       entry.LongestRun,
+		Registered:       registered,
+	}
 }
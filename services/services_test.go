@@ -0,0 +1,179 @@
+package services
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"sync"
+	"testing"
+
+	"task_one/dto"
+	"task_one/models"
+	"task_one/repository"
+)
+
+// fakeStringRepository keeps entries in memory and remembers the last
+// filter it was asked for
+type fakeStringRepository struct {
+	repository.StringRepository
+
+	mu         sync.Mutex
+	entries    map[string]models.StringEntry
+	lastFilter dto.FilterByCriteriaData
+	// saves counts the analyses saved per entry
+	saves map[string]int
+}
+
+func newFakeStringRepository() *fakeStringRepository {
+	return &fakeStringRepository{entries: map[string]models.StringEntry{}, saves: map[string]int{}}
+}
+
+func (r *fakeStringRepository) CreateNewStringRecord(ctx context.Context, stringData models.StringEntry) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := stringData.Collection + "/" + stringData.ID
+	if _, ok := r.entries[key]; ok {
+		return false, nil
+	}
+	r.entries[key] = stringData
+	return true, nil
+}
+
+func (r *fakeStringRepository) FilterByCriteria(ctx context.Context, collection string, input dto.FilterByCriteriaData) (*[]models.StringEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastFilter = input
+	var found []models.StringEntry
+	for _, entry := range r.entries {
+		if entry.Collection == collection {
+			found = append(found, entry)
+		}
+	}
+	return &found, nil
+}
+
+// stale reports whether entry is analyzed by an older version, as
+// staleCondition does for the analyzer version
+func stale(entry models.StringEntry) bool {
+	return entry.AnalyzerVersion < models.CurrentAnalyzerVersion
+}
+
+func (r *fakeStringRepository) CountStale(ctx context.Context) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var count int64
+	for _, entry := range r.entries {
+		if stale(entry) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ListStale pages in the order of the analyzer version index
+func (r *fakeStringRepository) ListStale(ctx context.Context, after repository.StaleCursor, limit int) (*[]models.StringEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var found []models.StringEntry
+	for _, entry := range r.entries {
+		if stale(entry) && cmp.Or(
+			cmp.Compare(entry.AnalyzerVersion, after.AnalyzerVersion),
+			cmp.Compare(entry.Collection, after.Collection),
+			cmp.Compare(entry.ID, after.ID)) > 0 {
+			found = append(found, entry)
+		}
+	}
+	slices.SortFunc(found, func(a, b models.StringEntry) int {
+		return cmp.Or(
+			cmp.Compare(a.AnalyzerVersion, b.AnalyzerVersion),
+			cmp.Compare(a.Collection, b.Collection),
+			cmp.Compare(a.ID, b.ID))
+	})
+	if len(found) > limit {
+		found = found[:limit]
+	}
+	return &found, nil
+}
+
+func (r *fakeStringRepository) SaveAnalysis(ctx context.Context, entries []models.StringEntry) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var saved int64
+	for _, entry := range entries {
+		key := entry.Collection + "/" + entry.ID
+		if stored, ok := r.entries[key]; !ok || !stale(stored) {
+			continue
+		}
+		r.entries[key] = entry
+		r.saves[key]++
+		saved++
+	}
+	return saved, nil
+}
+
+func newTestStringService(repo repository.StringRepository) StringService {
+	return NewStringService(repo, NewNaturalLanguageParser(nil), ValuePolicy{}, nil, slog.New(slog.DiscardHandler))
+}
+
+func TestCreateNewStringReportsCharacterStatistics(t *testing.T) {
+	service := newTestStringService(newFakeStringRepository())
+
+	tests := []struct {
+		value                                 string
+		entropy                               float64
+		letters, uppercase, vowels, consonant int
+	}{
+		{"aabb", 1, 4, 0, 2, 2},
+		{"Ünïcödé", math.Log2(7), 7, 1, 4, 3},
+		{"Привет", math.Log2(6), 6, 1, 0, 0},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			response, err := service.CreateNewString(context.Background(), DefaultCollection, dto.CreateNewStringEntryRequest{Value: tc.value})
+			if err != nil {
+				t.Fatal(err)
+			}
+			props := response.Properties
+			if props.Entropy == nil || math.Abs(*props.Entropy-tc.entropy) > 1e-9 {
+				t.Fatalf("entropy %v, want %v", props.Entropy, tc.entropy)
+			}
+			got := []*int{props.LetterCount, props.UppercaseCount, props.VowelCount, props.ConsonantCount}
+			want := []int{tc.letters, tc.uppercase, tc.vowels, tc.consonant}
+			for i, count := range got {
+				if count == nil || *count != want[i] {
+					t.Fatalf("letter, uppercase, vowel and consonant counts %v, want %v", deref(got), want)
+				}
+			}
+		})
+	}
+}
+
+func TestFilterByCriteriaReportsEntropyRange(t *testing.T) {
+	repo := newFakeStringRepository()
+	service := newTestStringService(repo)
+
+	minimum, maximum := 0.5, 1.5
+	input := dto.FilterByCriteriaData{Ranges: map[string]dto.Range{"entropy": {Min: &minimum, Max: &maximum}}}
+	response, err := service.FilterByCriteria(context.Background(), DefaultCollection, input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bounds := repo.lastFilter.Ranges["entropy"]; bounds.Min == nil || *bounds.Min != minimum || bounds.Max == nil || *bounds.Max != maximum {
+		t.Fatalf("repository got entropy range %+v, want [%v, %v]", bounds, minimum, maximum)
+	}
+	if response.FiltersApplied["min_entropy"] != minimum || response.FiltersApplied["max_entropy"] != maximum {
+		t.Fatalf("filters applied %v, want min_entropy %v and max_entropy %v", response.FiltersApplied, minimum, maximum)
+	}
+}
+
+func deref(counts []*int) []any {
+	values := make([]any, len(counts))
+	for i, count := range counts {
+		if count != nil {
+			values[i] = *count
+		}
+	}
+	return values
+}
@@ -2,8 +2,15 @@ package services
 
 import (
 	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"math"
 	"strings"
+	"task_one/models"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
 )
 
 // getLength returns the number of characters in the string
@@ -42,6 +49,15 @@ func GetHash(value string) string {
 	return fmt.Sprintf("%x", sum[:])
 }
 
+// IsValidHash reports whether id looks like a hex encoded SHA-256 hash
+func IsValidHash(id string) bool {
+	if len(id) != sha256.Size*2 {
+		return false
+	}
+	_, err := hex.DecodeString(id)
+	return err == nil
+}
+
 // getCharFreqMap returns a map of character to occurrence count
 func getCharFreqMap(value string) map[string]int {
 	freqMap := make(map[string]int)
@@ -60,3 +76,146 @@ func getWordCount(value string) int {
 	words := strings.Fields(value)
 	return len(words)
 }
+
+// getCharacterStatistics classifies every code point of the string in one
+// pass and computes its Shannon entropy
+func getCharacterStatistics(value string) models.CharacterStatistics {
+	var stats models.CharacterStatistics
+	counts := make(map[rune]int)
+	total := 0
+
+	var previous rune
+	run := 0
+	for _, r := range value {
+		total++
+		counts[r]++
+
+		if total > 1 && r == previous {
+			run++
+		} else {
+			run = 1
+		}
+		previous = r
+		stats.LongestRun = max(stats.LongestRun, run)
+
+		switch {
+		case unicode.IsLetter(r):
+			stats.Letters++
+			if isVowel(r) {
+				stats.Vowels++
+			} else if unicode.Is(unicode.Latin, r) {
+				stats.Consonants++
+			}
+		case unicode.IsDigit(r):
+			stats.Digits++
+		case unicode.IsSpace(r):
+			stats.Whitespace++
+		case unicode.IsPunct(r):
+			stats.Punctuation++
+		case unicode.IsSymbol(r):
+			stats.Symbols++
+		}
+		if unicode.IsUpper(r) {
+			stats.Uppercase++
+		} else if unicode.IsLower(r) {
+			stats.Lowercase++
+		}
+	}
+
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		stats.Entropy -= p * math.Log2(p)
+	}
+	return stats
+}
+
+// isVowel reports whether r is a, e, i, o or u in either case, with or
+// without diacritics. Vowels and consonants are only counted for the Latin
+// script, where other letters are consonants.
+func isVowel(r rune) bool {
+	if r >= utf8.RuneSelf {
+		// Decompose so é is read as e followed by a combining accent
+		base, _ := utf8.DecodeRuneInString(norm.NFD.String(string(r)))
+		r = base
+	}
+	switch unicode.ToLower(r) {
+	case 'a', 'e', 'i', 'o', 'u':
+		return true
+	}
+	return false
+}
+
+// normalizeTags applies the control character policy to tags, trims them,
+// drops duplicates and rejects empty ones
+func normalizeTags(tags []string, policy ValuePolicy) ([]string, error) {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]struct{})
+	for _, tag := range tags {
+		tag, ok := policy.applyControlChars(tag)
+		if !ok {
+			return nil, fmt.Errorf("invalid tags: tags cannot contain control characters")
+		}
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return nil, fmt.Errorf("invalid tags: tags cannot be empty")
+		}
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	return normalized, nil
+}
+
+// normalizeMetadata applies the control character policy to every key and
+// string in metadata, however deeply nested, and rejects empty top level
+// keys. Postgres cannot store NUL in jsonb, so it must not get that far.
+func normalizeMetadata(metadata map[string]any, policy ValuePolicy) (map[string]any, error) {
+	if metadata == nil {
+		return map[string]any{}, nil
+	}
+	normalized, ok := applyControlCharsToJSON(metadata, policy)
+	if !ok {
+		return nil, fmt.Errorf("invalid metadata: metadata cannot contain control characters")
+	}
+	metadata = normalized.(map[string]any)
+	for key := range metadata {
+		if strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid metadata: keys cannot be empty")
+		}
+	}
+	return metadata, nil
+}
+
+// applyControlCharsToJSON applies policy to the keys and strings of a
+// decoded JSON value, reporting false when policy rejects any of them
+func applyControlCharsToJSON(value any, policy ValuePolicy) (any, bool) {
+	switch value := value.(type) {
+	case string:
+		return policy.applyControlChars(value)
+	case map[string]any:
+		normalized := make(map[string]any, len(value))
+		for key, item := range value {
+			key, ok := policy.applyControlChars(key)
+			if !ok {
+				return nil, false
+			}
+			if normalized[key], ok = applyControlCharsToJSON(item, policy); !ok {
+				return nil, false
+			}
+		}
+		return normalized, true
+	case []any:
+		normalized := make([]any, len(value))
+		for i, item := range value {
+			var ok bool
+			if normalized[i], ok = applyControlCharsToJSON(item, policy); !ok {
+				return nil, false
+			}
+		}
+		return normalized, true
+	default:
+		return value, true
+	}
+}
@@ -0,0 +1,62 @@
+package services
+
+import (
+	"math"
+	"testing"
+
+	"task_one/models"
+)
+
+func TestCharacterStatistics(t *testing.T) {
+	tests := []struct {
+		value string
+		want  models.CharacterStatistics
+	}{
+		{"", models.CharacterStatistics{}},
+		{"aaaa", models.CharacterStatistics{Entropy: 0, Letters: 4, Lowercase: 4, Vowels: 4, LongestRun: 4}},
+		{"aabb", models.CharacterStatistics{Entropy: 1, Letters: 4, Lowercase: 4, Vowels: 2, Consonants: 2, LongestRun: 2}},
+		{"abcd", models.CharacterStatistics{Entropy: 2, Letters: 4, Lowercase: 4, Vowels: 1, Consonants: 3, LongestRun: 1}},
+		// Accented Latin vowels are vowels
+		{"Ünïcödé 123!", models.CharacterStatistics{
+			Entropy: math.Log2(12), Letters: 7, Digits: 3, Whitespace: 1, Punctuation: 1,
+			Uppercase: 1, Lowercase: 6, Vowels: 4, Consonants: 3, LongestRun: 1,
+		}},
+		// A combining accent is a code point of its own, but not a letter
+		{"e\u0301", models.CharacterStatistics{Entropy: 1, Letters: 1, Lowercase: 1, Vowels: 1, LongestRun: 1}},
+		// Vowels and consonants are only counted in Latin script
+		{"Привет мир", models.CharacterStatistics{
+			Entropy: entropyOf(1, 2, 2, 1, 1, 1, 1, 1), Letters: 9, Whitespace: 1,
+			Uppercase: 1, Lowercase: 8, LongestRun: 1,
+		}},
+		// Han has no case
+		{"日本語", models.CharacterStatistics{Entropy: math.Log2(3), Letters: 3, LongestRun: 1}},
+		{"€5 😀😀", models.CharacterStatistics{Entropy: entropyOf(1, 1, 1, 2), Digits: 1, Whitespace: 1, Symbols: 3, LongestRun: 2}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.value, func(t *testing.T) {
+			got := getCharacterStatistics(tc.value)
+			if math.Abs(got.Entropy-tc.want.Entropy) > 1e-9 {
+				t.Errorf("entropy %v, want %v", got.Entropy, tc.want.Entropy)
+			}
+			got.Entropy = tc.want.Entropy
+			if got != tc.want {
+				t.Errorf("got %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+// entropyOf is the Shannon entropy, in bits, of a value whose distinct code
+// points occur counts times
+func entropyOf(counts ...int) float64 {
+	total := 0
+	for _, count := range counts {
+		total += count
+	}
+	entropy := 0.0
+	for _, count := range counts {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
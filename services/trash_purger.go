@@ -0,0 +1,68 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"task_one/repository"
+	"time"
+)
+
+// TrashPurger periodically removes soft deleted strings that have been in the
+// trash for longer than the configured retention
+type TrashPurger struct {
+	stringRepo repository.StringRepository
+	retention  time.Duration
+	interval   time.Duration
+	logger     *slog.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewTrashPurger only needs the repository, since purging trashed strings
+// involves no analysis or parsing
+func NewTrashPurger(stringRepo repository.StringRepository, retentionDays int, interval time.Duration, logger *slog.Logger) *TrashPurger {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &TrashPurger{
+		stringRepo: stringRepo,
+		retention:  time.Duration(retentionDays) * 24 * time.Hour,
+		interval:   interval,
+		logger:     logger,
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Start runs the purge loop in the background. It is a no-op when the
+// retention or interval is not positive.
+func (p *TrashPurger) Start() {
+	if p.retention <= 0 || p.interval <= 0 {
+		p.logger.Info("Trash purge job disabled")
+		return
+	}
+	runEvery(p.ctx, &p.wg, p.interval, p.purge)
+}
+
+// Stop ends the purge loop, cancelling an in-flight purge, and waits for it
+// to return
+func (p *TrashPurger) Stop() {
+	p.cancel()
+	p.wg.Wait()
+}
+
+func (p *TrashPurger) purge() {
+	purged, err := p.stringRepo.PurgeDeletedBefore(p.ctx, time.Now().UTC().Add(-p.retention))
+	if err != nil {
+		// Shutting down mid purge is not a failure worth reporting
+		if p.ctx.Err() != nil {
+			return
+		}
+		p.logger.ErrorContext(p.ctx, "Failed to purge trash", slog.Any("error", err))
+		return
+	}
+	if purged > 0 {
+		p.logger.Info("Purged strings from the trash", slog.Int64("count", purged))
+	}
+}
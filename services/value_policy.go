@@ -0,0 +1,77 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Control character policies
+const (
+	ControlCharsReject = "reject"
+	ControlCharsStrip  = "strip"
+)
+
+// ValuePolicy bounds the strings accepted for analysis. Every path that
+// stores strings runs values through Apply. Zero limits are unlimited.
+type ValuePolicy struct {
+	MaxBytes     int
+	MaxRunes     int
+	MaxWords     int
+	ControlChars string
+}
+
+// Apply validates value and returns it with the control character policy
+// applied. Limit violations are reported as "too large" errors, malformed
+// content as "invalid value" errors.
+func (p ValuePolicy) Apply(value string) (string, error) {
+	if p.MaxBytes > 0 && len(value) > p.MaxBytes {
+		return "", fmt.Errorf("too large: value exceeds %d bytes", p.MaxBytes)
+	}
+	if !utf8.ValidString(value) {
+		return "", fmt.Errorf("invalid value: value is not valid UTF-8")
+	}
+
+	if strings.IndexFunc(value, isDisallowedControl) >= 0 {
+		var ok bool
+		if value, ok = p.applyControlChars(value); !ok {
+			return "", fmt.Errorf("invalid value: value contains control characters")
+		}
+		if value == "" {
+			return "", fmt.Errorf("invalid value: value is empty once control characters are removed")
+		}
+	}
+
+	if p.MaxRunes > 0 && utf8.RuneCountInString(value) > p.MaxRunes {
+		return "", fmt.Errorf("too large: value exceeds %d characters", p.MaxRunes)
+	}
+	if p.MaxWords > 0 && getWordCount(value) > p.MaxWords {
+		return "", fmt.Errorf("too large: value exceeds %d words", p.MaxWords)
+	}
+	return value, nil
+}
+
+// applyControlChars applies the control character policy to text, which is
+// the value or one of the tags and metadata stored with it. It reports false
+// when the policy rejects text.
+func (p ValuePolicy) applyControlChars(text string) (string, bool) {
+	if strings.IndexFunc(text, isDisallowedControl) < 0 {
+		return text, true
+	}
+	if p.ControlChars != ControlCharsStrip {
+		return "", false
+	}
+	return strings.Map(func(r rune) rune {
+		if isDisallowedControl(r) {
+			return -1
+		}
+		return r
+	}, text), true
+}
+
+// isDisallowedControl matches control characters other than tab, line feed
+// and carriage return. NUL in particular cannot be stored in Postgres text.
+func isDisallowedControl(r rune) bool {
+	return unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r'
+}